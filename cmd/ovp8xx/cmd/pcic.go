@@ -4,8 +4,15 @@ Copyright © 2024 Christian Ege <ch@ege.io>
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/graugans/go-ovp8xx/pkg/ovp8xx"
 	"github.com/graugans/go-ovp8xx/pkg/pcic"
 	"github.com/spf13/cobra"
 )
@@ -42,9 +49,9 @@ func (r *PCICReceiver) Notification(msg pcic.NotificationMessage) {
 
 // pcicCommand is a function that handles the execution of the "pcic" command.
 // It initializes a PCICReceiver, creates a helper, and establishes a connection to the PCIC client.
-// It then continuously processes incoming data using the PCIC client and the testHandler.
-// If an error occurs during any of these steps, it is returned.
-// Returns nil if the function completes successfully.
+// It then processes incoming data using the PCIC client and the testHandler until
+// the connection is lost or the command is interrupted by SIGINT or SIGTERM.
+// Returns nil if the command was interrupted.
 func pcicCommand(cmd *cobra.Command, args []string) error {
 	var testHandler *PCICReceiver = &PCICReceiver{}
 	var err error
@@ -53,22 +60,67 @@ func pcicCommand(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	pcic, err := pcic.NewPCICClient(
+	options := []pcic.PCICClientOption{
 		pcic.WithTCPClient(helper.hostname(), helper.remotePort()),
-	)
+	}
+	recorder, err := newRecorder(cmd, helper)
+	if err != nil {
+		return err
+	}
+	if recorder != nil {
+		defer recorder.Close()
+		options = append(options, pcic.WithRecorder(recorder))
+	}
+	pcic, err := pcic.NewPCICClient(options...)
 	if err != nil {
 		return err
 	}
-	for {
-		err = pcic.ProcessIncomming(testHandler)
-		if err != nil {
-			// An error occured, we break the loop
-			break
-		}
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = pcic.Run(ctx, testHandler)
+	if errors.Is(err, context.Canceled) {
+		// The command was interrupted by the user
+		return nil
 	}
 	return err
 }
 
+// newRecorder creates a pcic.Recorder in case the record flag is given.
+// The current configuration of the device is stored in the recording header.
+func newRecorder(cmd *cobra.Command, helper helperConfig) (*pcic.Recorder, error) {
+	path, err := cmd.Flags().GetString("record")
+	if err != nil || path == "" {
+		return nil, err
+	}
+	options := []pcic.RecorderOption{
+		pcic.WithSource(helper.hostname(), helper.remotePort()),
+	}
+	if compress, _ := cmd.Flags().GetBool("compress"); compress {
+		options = append(options, pcic.WithCompression())
+	}
+	maxSize, err := cmd.Flags().GetInt64("rotate-size")
+	if err != nil {
+		return nil, err
+	}
+	maxDuration, err := cmd.Flags().GetDuration("rotate-duration")
+	if err != nil {
+		return nil, err
+	}
+	if maxSize > 0 || maxDuration > 0 {
+		options = append(options, pcic.WithRotation(maxSize<<20, maxDuration))
+	}
+	o3r := ovp8xx.NewClient(ovp8xx.WithHost(helper.hostname()))
+	// The empty pointer requests the whole configuration on purpose, the
+	// snapshot shall describe the complete device state of the recording.
+	config, err := o3r.Get([]string{""})
+	if err != nil {
+		slog.Warn("Unable to get the device configuration for the recording", slog.Any("error", err))
+	} else {
+		options = append(options, pcic.WithDeviceConfig(config.String()))
+	}
+	return pcic.NewRecorder(path, options...)
+}
+
 // pcicCmd represents the pcic command
 var pcicCmd = &cobra.Command{
 	Use:   "pcic",
@@ -79,4 +131,8 @@ var pcicCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(pcicCmd)
 	pcicCmd.Flags().Uint16("port", 50010, "The port to connect to")
+	pcicCmd.Flags().String("record", "", "Record the raw PCIC messages to the given file")
+	pcicCmd.Flags().Bool("compress", false, "Compress the recording with gzip")
+	pcicCmd.Flags().Int64("rotate-size", 0, "Start a new recording file once the size in MiB is reached, 0 disables the limit")
+	pcicCmd.Flags().Duration("rotate-duration", 0, "Start a new recording file after the duration, e.g. 10m, 0 disables the limit")
 }
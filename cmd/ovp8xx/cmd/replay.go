@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Christian Ege <ch@ege.io>
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/spf13/cobra"
+)
+
+// replayCommand serves the recording files given as arguments until the
+// command is interrupted by SIGINT or SIGTERM.
+func replayCommand(cmd *cobra.Command, args []string) error {
+	options, err := replayOptions(cmd)
+	if err != nil {
+		return err
+	}
+	files := []string{}
+	for _, arg := range args {
+		// Expand the base name of a rotated recording into its files
+		recording, err := pcic.RecordingFiles(arg)
+		if err != nil {
+			return err
+		}
+		files = append(files, recording...)
+	}
+	replayer, err := pcic.NewReplayer(files, options...)
+	if err != nil {
+		return err
+	}
+	listen, err := cmd.Flags().GetString("listen")
+	if err != nil {
+		return err
+	}
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = replayer.ListenAndServe(ctx, listen)
+	if errors.Is(err, context.Canceled) {
+		// The command was interrupted by the user
+		return nil
+	}
+	return err
+}
+
+// replayOptions converts the flags of the replay command into pcic.ReplayerOptions
+func replayOptions(cmd *cobra.Command) ([]pcic.ReplayerOption, error) {
+	options := []pcic.ReplayerOption{}
+	mode, err := cmd.Flags().GetString("mode")
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "original":
+		options = append(options, pcic.WithOriginalTiming())
+	case "fast":
+		options = append(options, pcic.WithoutPacing())
+	case "rate":
+		fps, err := cmd.Flags().GetFloat64("fps")
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, pcic.WithFixedRate(fps))
+	default:
+		return nil, fmt.Errorf("unknown replay mode: %s", mode)
+	}
+	if loop, _ := cmd.Flags().GetBool("loop"); loop {
+		options = append(options, pcic.WithLoop())
+	}
+	first, err := cmd.Flags().GetInt("first")
+	if err != nil {
+		return nil, err
+	}
+	last, err := cmd.Flags().GetInt("last")
+	if err != nil {
+		return nil, err
+	}
+	return append(options, pcic.WithFrameRange(first, last)), nil
+}
+
+// replayCmd represents the replay command
+var replayCmd = &cobra.Command{
+	Use:   "replay <recording>...",
+	Short: "Serve PCIC recordings over TCP like a device",
+	Long: `Serve PCIC recordings over TCP like a device.
+
+A rotated recording can be given by the path passed to the --record flag of
+the pcic command, e.g. capture.pcic for capture-0001.pcic, capture-0002.pcic.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: replayCommand,
+}
+
+func init() {
+	rootCmd.AddCommand(replayCmd)
+	replayCmd.Flags().String("listen", "localhost:50010", "The address to listen on")
+	replayCmd.Flags().String("mode", "original", "The pacing of the messages, one of: original, fast, rate")
+	replayCmd.Flags().Float64("fps", 10, "The frame rate in the rate mode")
+	replayCmd.Flags().Bool("loop", false, "Start over once the end of the recording is reached")
+	replayCmd.Flags().Int("first", 0, "The index of the first frame to replay")
+	replayCmd.Flags().Int("last", -1, "The index of the last frame to replay, -1 for the end of the recording")
+}
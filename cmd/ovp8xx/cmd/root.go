@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 
 	"github.com/graugans/go-ovp8xx/pkg/ovp8xx"
@@ -17,9 +18,28 @@ func SetVersionInfo(version, commit, date string) {
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:          "ovp8xx",
-	Short:        "A command line application to interact with the ifm OVP8xx series of devices",
-	SilenceUsage: true,
+	Use:               "ovp8xx",
+	Short:             "A command line application to interact with the ifm OVP8xx series of devices",
+	SilenceUsage:      true,
+	PersistentPreRunE: setupLogging,
+}
+
+// setupLogging configures the default logger used by the ovp8xx and pcic
+// packages according to the log-level flag. The log is written to stderr
+// to keep the regular output on stdout intact.
+func setupLogging(cmd *cobra.Command, args []string) error {
+	levelName, err := cmd.Flags().GetString("log-level")
+	if err != nil {
+		return err
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("invalid log level %q, valid levels are: debug, info, warn, error", levelName)
+	}
+	slog.SetDefault(slog.New(
+		slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}),
+	))
+	return nil
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -33,4 +53,5 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().String("ip", ovp8xx.GetEnv("OVP8XX_IP", "192.168.0.69"), "The IP address or hostname of the OVP8XX. If not provided the default will be taken from the environment variable OVP8XX_IP")
+	rootCmd.PersistentFlags().String("log-level", "warn", "The log level, one of: debug, info, warn, error")
 }
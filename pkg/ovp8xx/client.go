@@ -2,24 +2,30 @@ package ovp8xx
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 )
 
 type (
 	ClientOption func(c *Client)
 	Client       struct {
-		host string
-		url  string
+		host   string
+		url    string
+		logger *slog.Logger
 	}
-	DiagnosisClient struct {
-		host string
-		url  string
+	DiagnosisClientOption func(c *DiagnosisClient)
+	DiagnosisClient       struct {
+		host   string
+		url    string
+		logger *slog.Logger
 	}
 )
 
 func NewClient(opts ...ClientOption) *Client {
 	// Initialise with default values
 	client := &Client{
-		host: GetEnv("OVP8XX_IP", "192.168.0.69"),
+		host:   GetEnv("OVP8XX_IP", "192.168.0.69"),
+		logger: slog.Default(),
 	}
 
 	// Apply options
@@ -36,9 +42,38 @@ func WithHost(host string) ClientOption {
 	}
 }
 
-func (device *Client) GetDiagnosticClient() *DiagnosisClient {
+// WithLogger sets the logger used to report the XML-RPC calls at debug level,
+// by default slog.Default() is used. A nil logger discards the messages.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = loggerOrDiscard(logger)
+	}
+}
+
+// WithDiagnosisLogger sets the logger of the DiagnosisClient,
+// by default the logger of the Client is used. A nil logger discards the messages.
+func WithDiagnosisLogger(logger *slog.Logger) DiagnosisClientOption {
+	return func(c *DiagnosisClient) {
+		c.logger = loggerOrDiscard(logger)
+	}
+}
+
+// loggerOrDiscard returns the logger or one which discards all messages in case it is nil
+func loggerOrDiscard(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+	return logger
+}
+
+func (device *Client) GetDiagnosticClient(opts ...DiagnosisClientOption) *DiagnosisClient {
 	client := &DiagnosisClient{}
 	client.host = device.host
+	client.logger = device.logger
+	// Apply options
+	for _, opt := range opts {
+		opt(client)
+	}
 	client.url = fmt.Sprintf("http://%s/api/rpc/v1/com.ifm.diagnostic/", client.host)
 	return client
 }
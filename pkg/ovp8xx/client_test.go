@@ -0,0 +1,30 @@
+package ovp8xx_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/ovp8xx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNilLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+	}))
+	defer server.Close()
+	address, err := url.Parse(server.URL)
+	assert.NoError(t, err, "We expect no error while parsing the server URL")
+
+	client := ovp8xx.NewClient(ovp8xx.WithHost(address.Host), ovp8xx.WithLogger(nil))
+	assert.NotPanics(t, func() {
+		_, _ = client.GetInit()
+	}, "We expect a nil logger not to panic")
+
+	diagnosis := client.GetDiagnosticClient(ovp8xx.WithDiagnosisLogger(nil))
+	assert.NotPanics(t, func() {
+		_, _ = diagnosis.GetFilterSchema()
+	}, "We expect a nil diagnosis logger not to panic")
+}
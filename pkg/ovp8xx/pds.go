@@ -0,0 +1,194 @@
+package ovp8xx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+type (
+	// PDSCommand is a command of the Pallet Detection System (PDS) application
+	PDSCommand interface {
+		// Name returns the name of the command as expected by the device, e.g. "getPallet"
+		Name() string
+		// Validate returns an error in case a parameter is out of range
+		Validate() error
+	}
+
+	// GetPalletCommand detects pallets in front of the camera
+	GetPalletCommand struct {
+		DepthHint   float64 `json:"depthHint"`   // The estimated distance to the pallet in meters, -1 for automatic detection
+		PalletIndex int     `json:"palletIndex"` // The type of the pallet: 0 block, 1 EPAL side, 2 stringer
+		PalletOrder string  `json:"palletOrder"` // The sort order of multiple pallets
+	}
+
+	// GetRackCommand detects the beam and the uprights of a rack
+	GetRackCommand struct {
+		DepthHint              float64 `json:"depthHint"`              // The estimated distance to the rack in meters
+		HorizontalDropPosition string  `json:"horizontalDropPosition"` // The horizontal drop position: left, right or center
+		VerticalDropPosition   string  `json:"verticalDropPosition"`   // The vertical drop position: interior or exterior
+		ZHint                  float64 `json:"zHint"`                  // The estimated z coordinate of the beam in the user frame in meters, may be zero or negative
+	}
+
+	// VolCheckCommand checks whether a volume is free of obstacles
+	VolCheckCommand struct {
+		XMin float64 `json:"xMin"`
+		XMax float64 `json:"xMax"`
+		YMin float64 `json:"yMin"`
+		YMax float64 `json:"yMax"`
+		ZMin float64 `json:"zMin"`
+		ZMax float64 `json:"zMax"`
+	}
+
+	// GetItemCommand detects an item, e.g. a trolley, in front of the camera
+	GetItemCommand struct {
+		DepthHint float64 `json:"depthHint"` // The estimated distance to the item in meters, -1 for automatic detection
+		ItemIndex int     `json:"itemIndex"` // The index of the item type
+		ItemOrder string  `json:"itemOrder"` // The sort order of multiple items
+	}
+)
+
+// The sort orders of the getPallet and getItem commands
+var pdsOrders = []string{"scoreDescending", "zDescending", "zAscending"}
+
+// Name returns "getPallet"
+func (c GetPalletCommand) Name() string {
+	return "getPallet"
+}
+
+// Validate checks the parameters of the getPallet command
+func (c GetPalletCommand) Validate() error {
+	return errors.Join(
+		validateDepthHint(c.DepthHint, true),
+		validateOneOf("palletIndex", c.PalletIndex, []int{0, 1, 2}),
+		validateOneOf("palletOrder", c.PalletOrder, pdsOrders),
+	)
+}
+
+// Name returns "getRack"
+func (c GetRackCommand) Name() string {
+	return "getRack"
+}
+
+// Validate checks the parameters of the getRack command
+func (c GetRackCommand) Validate() error {
+	return errors.Join(
+		validateDepthHint(c.DepthHint, false),
+		validateOneOf("horizontalDropPosition", c.HorizontalDropPosition, []string{"left", "right", "center"}),
+		validateOneOf("verticalDropPosition", c.VerticalDropPosition, []string{"interior", "exterior"}),
+		validateFinite("zHint", c.ZHint),
+	)
+}
+
+// Name returns "volCheck"
+func (c VolCheckCommand) Name() string {
+	return "volCheck"
+}
+
+// Validate checks that each minimum is smaller than its maximum
+func (c VolCheckCommand) Validate() error {
+	return errors.Join(
+		validateRange("x", c.XMin, c.XMax),
+		validateRange("y", c.YMin, c.YMax),
+		validateRange("z", c.ZMin, c.ZMax),
+	)
+}
+
+// Name returns "getItem"
+func (c GetItemCommand) Name() string {
+	return "getItem"
+}
+
+// Validate checks the parameters of the getItem command
+func (c GetItemCommand) Validate() error {
+	var err error
+	if c.ItemIndex < 0 {
+		err = fmt.Errorf("the itemIndex: %d must not be negative", c.ItemIndex)
+	}
+	return errors.Join(
+		validateDepthHint(c.DepthHint, true),
+		err,
+		validateOneOf("itemOrder", c.ItemOrder, pdsOrders),
+	)
+}
+
+func validateDepthHint(depthHint float64, automatic bool) error {
+	if isPositive(depthHint) || (automatic && depthHint == -1) {
+		return nil
+	}
+	if automatic {
+		return fmt.Errorf("the depthHint: %g has to be positive or -1", depthHint)
+	}
+	return fmt.Errorf("the depthHint: %g has to be positive", depthHint)
+}
+
+func validateFinite(name string, value float64) error {
+	if !math.IsNaN(value) && !math.IsInf(value, 0) {
+		return nil
+	}
+	return fmt.Errorf("the %s: %g has to be finite", name, value)
+}
+
+// isPositive reports whether the value is positive and finite
+func isPositive(value float64) bool {
+	return value > 0 && !math.IsInf(value, 1)
+}
+
+func validateOneOf[T comparable](name string, value T, valid []T) error {
+	if slices.Contains(valid, value) {
+		return nil
+	}
+	return fmt.Errorf("the %s: %v is not one of %v", name, value, valid)
+}
+
+func validateRange(axis string, min, max float64) error {
+	if math.IsInf(min, 0) || math.IsInf(max, 0) {
+		return fmt.Errorf("the %s range: %g to %g has to be finite", axis, min, max)
+	}
+	if min < max {
+		return nil
+	}
+	return fmt.Errorf("the %sMin: %g has to be smaller than the %sMax: %g", axis, min, axis, max)
+}
+
+// NewPDSCommandConfig creates the configuration which triggers the command on the PDS application instance app, e.g. "app0"
+func NewPDSCommandConfig(app string, command PDSCommand) (*Config, error) {
+	if app == "" {
+		return nil, errors.New("the PDS application instance must not be empty")
+	}
+	if err := command.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s command: %w", command.Name(), err)
+	}
+	data, err := json.Marshal(map[string]any{
+		"applications": map[string]any{
+			"instances": map[string]any{
+				app: map[string]any{
+					"configuration": map[string]any{
+						"customization": map[string]any{
+							"command":      command.Name(),
+							command.Name(): command,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return NewConfig(WitJSONString(string(data))), nil
+}
+
+// PDS triggers the command on the PDS application instance app, e.g. "app0"
+//
+// The result is delivered as a JSON chunk by the PCIC output of the
+// application, see the pcic package for the decoding.
+func (device *Client) PDS(app string, command PDSCommand) error {
+	conf, err := NewPDSCommandConfig(app, command)
+	if err != nil {
+		return err
+	}
+	return device.Set(*conf)
+}
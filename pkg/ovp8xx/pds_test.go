@@ -0,0 +1,85 @@
+package ovp8xx_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/ovp8xx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPDSCommandValidate(t *testing.T) {
+	pallet := ovp8xx.GetPalletCommand{DepthHint: 1.5, PalletIndex: 1, PalletOrder: "scoreDescending"}
+	rack := ovp8xx.GetRackCommand{DepthHint: 2, HorizontalDropPosition: "left", VerticalDropPosition: "interior", ZHint: 1.2}
+	volume := ovp8xx.VolCheckCommand{XMin: 1, XMax: 2, YMin: -0.5, YMax: 0.5, ZMin: 0, ZMax: 1}
+	item := ovp8xx.GetItemCommand{DepthHint: -1, ItemIndex: 0, ItemOrder: "zAscending"}
+	for _, tc := range []struct {
+		name    string
+		command ovp8xx.PDSCommand
+		valid   bool
+	}{
+		{"pallet", pallet, true},
+		{"pallet automatic depth", func() ovp8xx.PDSCommand { c := pallet; c.DepthHint = -1; return c }(), true},
+		{"pallet zero depth", func() ovp8xx.PDSCommand { c := pallet; c.DepthHint = 0; return c }(), false},
+		{"pallet infinite depth", func() ovp8xx.PDSCommand { c := pallet; c.DepthHint = math.Inf(1); return c }(), false},
+		{"pallet NaN depth", func() ovp8xx.PDSCommand { c := pallet; c.DepthHint = math.NaN(); return c }(), false},
+		{"pallet index", func() ovp8xx.PDSCommand { c := pallet; c.PalletIndex = 3; return c }(), false},
+		{"pallet order", func() ovp8xx.PDSCommand { c := pallet; c.PalletOrder = "random"; return c }(), false},
+		{"rack", rack, true},
+		{"rack automatic depth", func() ovp8xx.PDSCommand { c := rack; c.DepthHint = -1; return c }(), false},
+		{"rack horizontal position", func() ovp8xx.PDSCommand { c := rack; c.HorizontalDropPosition = "top"; return c }(), false},
+		{"rack vertical position", func() ovp8xx.PDSCommand { c := rack; c.VerticalDropPosition = "above"; return c }(), false},
+		{"rack zero z hint", func() ovp8xx.PDSCommand { c := rack; c.ZHint = 0; return c }(), true},
+		{"rack negative z hint", func() ovp8xx.PDSCommand { c := rack; c.ZHint = -1.5; return c }(), true},
+		{"rack negative infinite z hint", func() ovp8xx.PDSCommand { c := rack; c.ZHint = math.Inf(-1); return c }(), false},
+		{"rack infinite z hint", func() ovp8xx.PDSCommand { c := rack; c.ZHint = math.Inf(1); return c }(), false},
+		{"rack NaN z hint", func() ovp8xx.PDSCommand { c := rack; c.ZHint = math.NaN(); return c }(), false},
+		{"volume", volume, true},
+		{"volume empty x", func() ovp8xx.PDSCommand { c := volume; c.XMax = c.XMin; return c }(), false},
+		{"volume inverted y", func() ovp8xx.PDSCommand { c := volume; c.YMin, c.YMax = c.YMax, c.YMin; return c }(), false},
+		{"volume infinite z", func() ovp8xx.PDSCommand { c := volume; c.ZMax = math.Inf(1); return c }(), false},
+		{"volume NaN x", func() ovp8xx.PDSCommand { c := volume; c.XMin = math.NaN(); return c }(), false},
+		{"item", item, true},
+		{"item negative index", func() ovp8xx.PDSCommand { c := item; c.ItemIndex = -1; return c }(), false},
+		{"item order", func() ovp8xx.PDSCommand { c := item; c.ItemOrder = ""; return c }(), false},
+		{"item depth", func() ovp8xx.PDSCommand { c := item; c.DepthHint = -2; return c }(), false},
+	} {
+		err := tc.command.Validate()
+		if tc.valid {
+			assert.NoError(t, err, "We expect the %s command to be valid", tc.name)
+		} else {
+			assert.Error(t, err, "We expect the %s command to be invalid", tc.name)
+		}
+	}
+}
+
+func TestPDSCommandNames(t *testing.T) {
+	assert.Equal(t, "getPallet", ovp8xx.GetPalletCommand{}.Name())
+	assert.Equal(t, "getRack", ovp8xx.GetRackCommand{}.Name())
+	assert.Equal(t, "volCheck", ovp8xx.VolCheckCommand{}.Name())
+	assert.Equal(t, "getItem", ovp8xx.GetItemCommand{}.Name())
+}
+
+func TestNewPDSCommandConfig(t *testing.T) {
+	config, err := ovp8xx.NewPDSCommandConfig("app0", ovp8xx.GetRackCommand{
+		DepthHint:              2,
+		HorizontalDropPosition: "center",
+		VerticalDropPosition:   "exterior",
+		ZHint:                  1.2,
+	})
+	assert.NoError(t, err, "We expect no error while creating the configuration")
+	assert.JSONEq(t, `{"applications": {"instances": {"app0": {"configuration": {"customization": {
+		"command": "getRack",
+		"getRack": {
+			"depthHint": 2,
+			"horizontalDropPosition": "center",
+			"verticalDropPosition": "exterior",
+			"zHint": 1.2
+		}
+	}}}}}}`, config.String(), "A configuration mismatch occurred")
+
+	_, err = ovp8xx.NewPDSCommandConfig("", ovp8xx.VolCheckCommand{XMax: 1, YMax: 1, ZMax: 1})
+	assert.Error(t, err, "We expect an error without an application instance")
+	_, err = ovp8xx.NewPDSCommandConfig("app0", ovp8xx.VolCheckCommand{})
+	assert.Error(t, err, "We expect an error for an invalid command")
+}
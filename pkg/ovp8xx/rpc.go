@@ -1,16 +1,32 @@
 package ovp8xx
 
 import (
+	"log/slog"
+	"time"
+
 	"alexejk.io/go-xmlrpc"
 )
 
-func (device *Client) Get(pointers []string) (Config, error) {
-	client, err := xmlrpc.NewClient(device.url)
+// call performs a single XML-RPC call and logs the method name and the duration
+func call(logger *slog.Logger, url, method string, args, reply any) error {
+	client, err := xmlrpc.NewClient(url)
 	if err != nil {
-		return *NewConfig(), err
+		return err
 	}
 	defer client.Close()
 
+	start := time.Now()
+	err = client.Call(method, args, reply)
+	logger.Debug("XML-RPC call",
+		slog.String("url", url),
+		slog.String("method", method),
+		slog.Duration("duration", time.Since(start)),
+		slog.Any("error", err),
+	)
+	return err
+}
+
+func (device *Client) Get(pointers []string) (Config, error) {
 	result := &struct {
 		JSON string
 	}{}
@@ -19,7 +35,7 @@ func (device *Client) Get(pointers []string) (Config, error) {
 		Pointers []string
 	}{Pointers: pointers}
 
-	if err = client.Call("get", arg, result); err != nil {
+	if err := call(device.logger, device.url, "get", arg, result); err != nil {
 		return *NewConfig(), err
 	}
 
@@ -27,17 +43,11 @@ func (device *Client) Get(pointers []string) (Config, error) {
 }
 
 func (device *Client) Set(conf Config) error {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-
 	arg := &struct {
 		Data string
 	}{Data: conf.String()}
 
-	if err := client.Call("set", arg, nil); err != nil {
+	if err := call(device.logger, device.url, "set", arg, nil); err != nil {
 		return err
 	}
 
@@ -45,17 +55,11 @@ func (device *Client) Set(conf Config) error {
 }
 
 func (device *Client) GetInit() (Config, error) {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return *NewConfig(), err
-	}
-	defer client.Close()
-
 	result := &struct {
 		JSON string
 	}{}
 
-	if err = client.Call("getInit", nil, result); err != nil {
+	if err := call(device.logger, device.url, "getInit", nil, result); err != nil {
 		return *NewConfig(), err
 	}
 
@@ -63,65 +67,41 @@ func (device *Client) GetInit() (Config, error) {
 }
 
 func (device *Client) SaveInit(pointers []string) error {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-
 	// In case no pointer is given save the complete configuration
 	if len(pointers) == 0 {
-		return client.Call("saveInit", nil, nil)
+		return call(device.logger, device.url, "saveInit", nil, nil)
 	}
 
 	arg := &struct {
 		Pointers []string
 	}{Pointers: pointers}
-	return client.Call("saveInit", arg, nil)
+	return call(device.logger, device.url, "saveInit", arg, nil)
 }
 
 func (device *Client) FactoryReset(keepNetworkSettings bool) error {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-
 	arg := &struct {
 		KeepNetworkSettings bool
 	}{
 		KeepNetworkSettings: keepNetworkSettings,
 	}
-	return client.Call("factoryReset", arg, nil)
+	return call(device.logger, device.url, "factoryReset", arg, nil)
 }
 
 func (device *Client) GetSchema(pointers []string) (string, error) {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return "", err
-	}
-	defer client.Close()
-
 	result := &struct {
 		JSON string
 	}{}
 	arg := &struct {
 		Pointers []string
 	}{Pointers: pointers}
-	if err := client.Call("getSchema", arg, result); err != nil {
+	if err := call(device.logger, device.url, "getSchema", arg, result); err != nil {
 		return "", err
 	}
 	return result.JSON, nil
 }
 
 func (device *Client) Reboot() error {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-
-	return client.Call("reboot", nil, nil)
+	return call(device.logger, device.url, "reboot", nil, nil)
 }
 
 // RebootToSWUpdate reboots the OVP8xx device into software update mode.
@@ -129,21 +109,10 @@ func (device *Client) Reboot() error {
 // This method is typically used to initiate a firmware update on the device.
 // Returns an error if there was a problem establishing the connection or calling the method.
 func (device *Client) RebootToSWUpdate() error {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return err
-	}
-	defer client.Close()
-	return client.Call("rebootToRecovery", nil, nil)
+	return call(device.logger, device.url, "rebootToRecovery", nil, nil)
 }
 
 func (device *DiagnosisClient) GetFiltered(conf Config) (Config, error) {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return *NewConfig(), err
-	}
-	defer client.Close()
-
 	arg := &struct {
 		Data string
 	}{Data: conf.String()}
@@ -152,7 +121,7 @@ func (device *DiagnosisClient) GetFiltered(conf Config) (Config, error) {
 		JSON string
 	}{}
 
-	if err = client.Call("getFiltered", arg, result); err != nil {
+	if err := call(device.logger, device.url, "getFiltered", arg, result); err != nil {
 		return *NewConfig(), err
 	}
 
@@ -160,17 +129,11 @@ func (device *DiagnosisClient) GetFiltered(conf Config) (Config, error) {
 }
 
 func (device *DiagnosisClient) GetFilterSchema() (Config, error) {
-	client, err := xmlrpc.NewClient(device.url)
-	if err != nil {
-		return *NewConfig(), err
-	}
-	defer client.Close()
-
 	result := &struct {
 		JSON string
 	}{}
 
-	if err = client.Call("getFilterSchema", nil, result); err != nil {
+	if err := call(device.logger, device.url, "getFilterSchema", nil, result); err != nil {
 		return *NewConfig(), err
 	}
 
@@ -0,0 +1,75 @@
+package pcic
+
+import "math"
+
+// The number of parameters of an intrinsic or inverse intrinsic camera model
+const intrinsicParameterCount = 32
+
+type (
+	// Extrinsic is the transformation from the optical to the user coordinate system
+	//
+	// The translation is given in meters and the rotation in radians. The
+	// rotation is applied in the order X, Y and Z: R = Rx(RotX) * Ry(RotY) * Rz(RotZ)
+	Extrinsic struct {
+		TransX float32
+		TransY float32
+		TransZ float32
+		RotX   float32
+		RotY   float32
+		RotZ   float32
+	}
+
+	// Intrinsic is the calibration of a camera model identified by its ModelID
+	Intrinsic struct {
+		ModelID    uint32
+		Parameters [intrinsicParameterCount]float32
+	}
+
+	// Point is a point in a three dimensional coordinate system, in meters
+	Point struct {
+		X float32
+		Y float32
+		Z float32
+	}
+)
+
+// Transform maps the point p from the optical to the user coordinate system
+func (e Extrinsic) Transform(p Point) Point {
+	m := e.transformation()
+	return m.apply(float64(p.X), float64(p.Y), float64(p.Z))
+}
+
+// Rotate maps the direction p to the user coordinate system, only the rotation is applied
+func (e Extrinsic) Rotate(p Point) Point {
+	m := e.transformation()
+	m.t = [3]float64{}
+	return m.apply(float64(p.X), float64(p.Y), float64(p.Z))
+}
+
+// transformation is a rotation matrix followed by a translation
+type transformation struct {
+	r [3][3]float64
+	t [3]float64
+}
+
+func (e Extrinsic) transformation() transformation {
+	sx, cx := math.Sincos(float64(e.RotX))
+	sy, cy := math.Sincos(float64(e.RotY))
+	sz, cz := math.Sincos(float64(e.RotZ))
+	return transformation{
+		r: [3][3]float64{
+			{cy * cz, -cy * sz, sy},
+			{cx*sz + cz*sx*sy, cx*cz - sx*sy*sz, -cy * sx},
+			{sx*sz - cx*cz*sy, cz*sx + cx*sy*sz, cx * cy},
+		},
+		t: [3]float64{float64(e.TransX), float64(e.TransY), float64(e.TransZ)},
+	}
+}
+
+func (m *transformation) apply(x, y, z float64) Point {
+	return Point{
+		X: float32(m.r[0][0]*x + m.r[0][1]*y + m.r[0][2]*z + m.t[0]),
+		Y: float32(m.r[1][0]*x + m.r[1][1]*y + m.r[1][2]*z + m.t[1]),
+		Z: float32(m.r[2][0]*x + m.r[2][1]*y + m.r[2][2]*z + m.t[2]),
+	}
+}
@@ -0,0 +1,152 @@
+package pcic
+
+import (
+	"fmt"
+	"math"
+)
+
+// The ifm intrinsic camera models
+//
+// The intrinsic models map a pixel to the ray in the optical coordinate
+// system, the inverse models map a point of the optical coordinate system
+// onto the image plane.
+const (
+	MODEL_BOUGUET         uint32 = 0 /* The pinhole model with radial and tangential distortion */
+	MODEL_BOUGUET_INVERSE uint32 = 1 /* The inverse of the Bouguet model */
+	MODEL_FISHEYE         uint32 = 2 /* The fish eye model with radial distortion */
+	MODEL_FISHEYE_INVERSE uint32 = 3 /* The inverse of the fish eye model */
+)
+
+type (
+	// ImagePoint is a position on the image plane
+	//
+	// The top left corner of the image is at (0, 0), so the center of the
+	// pixel in the column x and the row y is at (x+0.5, y+0.5).
+	ImagePoint struct {
+		U float64
+		V float64
+	}
+
+	// CameraModel maps between points in the optical coordinate system and the image plane
+	CameraModel struct {
+		intrinsic Intrinsic
+		inverse   Intrinsic
+	}
+)
+
+// NewCameraModel creates a CameraModel from the intrinsic and the inverse intrinsic calibration
+//
+// The intrinsic calibration is used by Unproject and UnitVectors, the inverse
+// intrinsic calibration by Project.
+func NewCameraModel(intrinsic, inverse Intrinsic) (*CameraModel, error) {
+	if intrinsic.ModelID != MODEL_BOUGUET && intrinsic.ModelID != MODEL_FISHEYE {
+		return nil, fmt.Errorf("unsupported intrinsic model: %d", intrinsic.ModelID)
+	}
+	if inverse.ModelID != MODEL_BOUGUET_INVERSE && inverse.ModelID != MODEL_FISHEYE_INVERSE {
+		return nil, fmt.Errorf("unsupported inverse intrinsic model: %d", inverse.ModelID)
+	}
+	return &CameraModel{intrinsic: intrinsic, inverse: inverse}, nil
+}
+
+// CameraModel returns the CameraModel of the 3D data
+func (info *TOFInfo) CameraModel() (*CameraModel, error) {
+	return NewCameraModel(info.IntrinsicCalibration, info.InverseIntrinsicCalibration)
+}
+
+// CameraModel returns the CameraModel of the 2D data
+func (info *RGBInfo) CameraModel() (*CameraModel, error) {
+	return NewCameraModel(info.IntrinsicCalibration, info.InverseIntrinsicCalibration)
+}
+
+// Project maps the point p of the optical coordinate system onto the image plane
+//
+// The result is false in case the point can not be projected, e.g. a point
+// behind the camera for the Bouguet model.
+func (m *CameraModel) Project(p Point) (ImagePoint, bool) {
+	k := params(m.inverse)
+	fx, fy, mx, my, alpha := k[0], k[1], k[2], k[3], k[4]
+	x, y, z := float64(p.X), float64(p.Y), float64(p.Z)
+	var ixd, iyd float64
+	switch m.inverse.ModelID {
+	case MODEL_BOUGUET_INVERSE:
+		if z <= 0 {
+			return ImagePoint{}, false
+		}
+		ixd, iyd = bouguetDistortion(x/z, y/z, k)
+	case MODEL_FISHEYE_INVERSE:
+		k1, k2, k3, k4, thetaMax := k[5], k[6], k[7], k[8], k[9]
+		lxy := math.Hypot(x, y)
+		if lxy == 0 && z <= 0 {
+			return ImagePoint{}, false
+		}
+		theta := math.Atan2(lxy, z)
+		phi := math.Min(theta, thetaMax)
+		phi *= phi
+		thetaS := theta * (1 + phi*(k1+phi*(k2+phi*(k3+phi*k4))))
+		if lxy > 0 {
+			ixd, iyd = thetaS*x/lxy, thetaS*y/lxy
+		}
+	}
+	return ImagePoint{
+		U: fx*(ixd+alpha*iyd) + mx,
+		V: fy*iyd + my,
+	}, true
+}
+
+// Unproject returns the unit vector of the ray through the pixel in the optical coordinate system
+func (m *CameraModel) Unproject(px ImagePoint) Point {
+	k := params(m.intrinsic)
+	fx, fy, mx, my, alpha := k[0], k[1], k[2], k[3], k[4]
+	cy := (px.V - my) / fy
+	cx := (px.U-mx)/fx - alpha*cy
+	var x, y, z float64
+	switch m.intrinsic.ModelID {
+	case MODEL_BOUGUET:
+		x, y = bouguetDistortion(cx, cy, k)
+		z = 1
+	case MODEL_FISHEYE:
+		k1, k2, k3, k4, thetaMax := k[5], k[6], k[7], k[8], k[9]
+		thetaS := math.Hypot(cx, cy)
+		phi := math.Min(thetaS, thetaMax)
+		phi *= phi
+		theta := thetaS * (1 + phi*(k1+phi*(k2+phi*(k3+phi*k4))))
+		z = math.Cos(theta)
+		if thetaS > 0 {
+			sin := math.Sin(theta)
+			x, y = sin*cx/thetaS, sin*cy/thetaS
+		}
+	}
+	norm := math.Sqrt(x*x + y*y + z*z)
+	return Point{X: float32(x / norm), Y: float32(y / norm), Z: float32(z / norm)}
+}
+
+// UnitVectors computes the unit vector of each pixel center in row major order
+//
+// The result matches the layout of the UNIT_VECTOR_ALL chunk.
+func (m *CameraModel) UnitVectors(width, height int) []Point {
+	vectors := make([]Point, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			vectors = append(vectors, m.Unproject(ImagePoint{U: float64(x) + 0.5, V: float64(y) + 0.5}))
+		}
+	}
+	return vectors
+}
+
+// bouguetDistortion applies the radial and tangential distortion of the Bouguet model
+func bouguetDistortion(x, y float64, k [intrinsicParameterCount]float64) (float64, float64) {
+	k1, k2, k5, k3, k4 := k[5], k[6], k[7], k[8], k[9]
+	rd2 := x*x + y*y
+	radial := 1 + rd2*(k1+rd2*(k2+rd2*k5))
+	h := 2 * x * y
+	return x*radial + k3*h + k4*(rd2+2*x*x),
+		y*radial + k3*(rd2+2*y*y) + k4*h
+}
+
+func params(intrinsic Intrinsic) [intrinsicParameterCount]float64 {
+	k := [intrinsicParameterCount]float64{}
+	for i, v := range intrinsic.Parameters {
+		k[i] = float64(v)
+	}
+	return k
+}
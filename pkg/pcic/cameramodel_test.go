@@ -0,0 +1,131 @@
+package pcic_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func intrinsic(model uint32, params ...float32) pcic.Intrinsic {
+	intrinsic := pcic.Intrinsic{ModelID: model}
+	copy(intrinsic.Parameters[:], params)
+	return intrinsic
+}
+
+func TestBouguetModel(t *testing.T) {
+	model, err := pcic.NewCameraModel(
+		intrinsic(pcic.MODEL_BOUGUET, 100, 100, 50, 40),
+		intrinsic(pcic.MODEL_BOUGUET_INVERSE, 100, 100, 50, 40),
+	)
+	assert.NoError(t, err, "We expect no error while creating the camera model")
+	px, ok := model.Project(pcic.Point{X: 1, Y: 2, Z: 10})
+	assert.True(t, ok, "We expect the point to be projected")
+	assert.InDelta(t, 60, px.U, 1e-9, "An U mismatch occurred")
+	assert.InDelta(t, 60, px.V, 1e-9, "A V mismatch occurred")
+	_, ok = model.Project(pcic.Point{X: 1, Y: 2, Z: -10})
+	assert.False(t, ok, "We expect a point behind the camera not to be projected")
+
+	ray := model.Unproject(pcic.ImagePoint{U: 60, V: 60})
+	norm := float32(math.Sqrt(1.05))
+	assertPoint(t, pcic.Point{X: 0.1 / norm, Y: 0.2 / norm, Z: 1 / norm}, ray)
+}
+
+func TestBouguetDistortion(t *testing.T) {
+	// The projection uses the inverse model with k1 = 0.1 and the tangential coefficients k3 = 0.01 and k4 = 0.02
+	model, err := pcic.NewCameraModel(
+		intrinsic(pcic.MODEL_BOUGUET, 100, 100, 50, 40),
+		intrinsic(pcic.MODEL_BOUGUET_INVERSE, 100, 100, 50, 40, 0, 0.1, 0, 0, 0.01, 0.02),
+	)
+	assert.NoError(t, err, "We expect no error while creating the camera model")
+	px, ok := model.Project(pcic.Point{X: 0.5, Y: 0.5, Z: 1})
+	assert.True(t, ok, "We expect the point to be projected")
+	// rd2 = 0.5, radial = 1.05, tangential x = 0.01*0.5 + 0.02*1.0, y = 0.01*1.0 + 0.02*0.5
+	assert.InDelta(t, 100*(0.525+0.025)+50, px.U, 1e-6, "An U mismatch occurred")
+	assert.InDelta(t, 100*(0.525+0.02)+40, px.V, 1e-6, "A V mismatch occurred")
+}
+
+func TestCameraModelRoundTrip(t *testing.T) {
+	for _, models := range [][2]pcic.Intrinsic{
+		{
+			// The inverse coefficients are the first order inverse of the intrinsic ones
+			intrinsic(pcic.MODEL_BOUGUET, 100, 100, 50, 40, 0, 0.02, 0.001),
+			intrinsic(pcic.MODEL_BOUGUET_INVERSE, 100, 100, 50, 40, 0, -0.02, 0.0002),
+		},
+		{
+			intrinsic(pcic.MODEL_FISHEYE, 100, 100, 50, 40, 0, 0.02, 0.001, 0, 0, math.Pi),
+			intrinsic(pcic.MODEL_FISHEYE_INVERSE, 100, 100, 50, 40, 0, -0.02, 0.0002, 0, 0, math.Pi),
+		},
+	} {
+		model, err := pcic.NewCameraModel(models[0], models[1])
+		assert.NoError(t, err, "We expect no error while creating the camera model")
+		for _, px := range []pcic.ImagePoint{{U: 50, V: 40}, {U: 70, V: 30}, {U: 20, V: 65}} {
+			ray := model.Unproject(px)
+			projected, ok := model.Project(ray)
+			assert.True(t, ok, "We expect the ray to be projected")
+			assert.InDelta(t, px.U, projected.U, 0.01, "An U mismatch occurred for model %d", models[0].ModelID)
+			assert.InDelta(t, px.V, projected.V, 0.01, "A V mismatch occurred for model %d", models[0].ModelID)
+		}
+	}
+
+	// The intrinsic model maps the pixel to the ray, a swap of the models changes the sign of the distortion
+	model, err := pcic.NewCameraModel(
+		intrinsic(pcic.MODEL_BOUGUET, 100, 100, 50, 40, 0, 0.1),
+		intrinsic(pcic.MODEL_BOUGUET_INVERSE, 100, 100, 50, 40, 0, -0.1),
+	)
+	assert.NoError(t, err, "We expect no error while creating the camera model")
+	ray := model.Unproject(pcic.ImagePoint{U: 100, V: 40})
+	// cx = 0.5, cy = 0 and the radial distortion 1 + 0.1*0.25
+	norm := float32(math.Sqrt(0.5125*0.5125 + 1))
+	assertPoint(t, pcic.Point{X: 0.5125 / norm, Y: 0, Z: 1 / norm}, ray)
+	px, _ := model.Project(pcic.Point{X: 0.5, Y: 0, Z: 1})
+	assert.InDelta(t, 100*0.5*(1-0.1*0.25)+50, px.U, 1e-6, "We expect the inverse model to be used for the projection")
+}
+
+func TestFisheyeModel(t *testing.T) {
+	model, err := pcic.NewCameraModel(
+		intrinsic(pcic.MODEL_FISHEYE, 100, 100, 50, 40, 0, 0, 0, 0, 0, math.Pi),
+		intrinsic(pcic.MODEL_FISHEYE_INVERSE, 100, 100, 50, 40, 0, 0, 0, 0, 0, math.Pi),
+	)
+	assert.NoError(t, err, "We expect no error while creating the camera model")
+	// Without distortion the distance to the principal point is proportional to the angle
+	px, ok := model.Project(pcic.Point{X: 1, Y: 0, Z: 1})
+	assert.True(t, ok, "We expect the point to be projected")
+	assert.InDelta(t, 100*math.Pi/4+50, px.U, 1e-4, "An U mismatch occurred")
+	assert.InDelta(t, 40, px.V, 1e-9, "A V mismatch occurred")
+	px, ok = model.Project(pcic.Point{X: 0, Y: 1, Z: -1})
+	assert.True(t, ok, "We expect a point beyond 90 degrees to be projected")
+	assert.InDelta(t, 100*3*math.Pi/4+40, px.V, 1e-4, "A V mismatch occurred")
+
+	for _, p := range []pcic.Point{{X: 0.3, Y: -0.2, Z: 1}, {X: 0, Y: 0, Z: 1}, {X: -2, Y: 1, Z: 0.5}} {
+		px, _ := model.Project(p)
+		ray := model.Unproject(px)
+		norm := float32(math.Sqrt(float64(p.X*p.X + p.Y*p.Y + p.Z*p.Z)))
+		assertPoint(t, pcic.Point{X: p.X / norm, Y: p.Y / norm, Z: p.Z / norm}, ray)
+	}
+}
+
+func TestCameraModelUnitVectors(t *testing.T) {
+	info := pcic.TOFInfo{
+		IntrinsicCalibration:        intrinsic(pcic.MODEL_BOUGUET, 1, 1, 1, 0.5),
+		InverseIntrinsicCalibration: intrinsic(pcic.MODEL_BOUGUET_INVERSE, 1, 1, 1, 0.5),
+	}
+	model, err := info.CameraModel()
+	assert.NoError(t, err, "We expect no error while creating the camera model")
+	vectors := model.UnitVectors(2, 1)
+	assert.Equal(t, 2, len(vectors), "A unit vector count mismatch occurred")
+	norm := float32(math.Sqrt(1.25))
+	assertPoint(t, pcic.Point{X: -0.5 / norm, Y: 0, Z: 1 / norm}, vectors[0])
+	assertPoint(t, pcic.Point{X: 0.5 / norm, Y: 0, Z: 1 / norm}, vectors[1])
+}
+
+func TestCameraModelUnsupported(t *testing.T) {
+	_, err := pcic.NewCameraModel(intrinsic(7), intrinsic(pcic.MODEL_BOUGUET_INVERSE))
+	assert.Error(t, err, "We expect an error due to an unknown model")
+	_, err = pcic.NewCameraModel(intrinsic(pcic.MODEL_BOUGUET), intrinsic(pcic.MODEL_BOUGUET))
+	assert.Error(t, err, "We expect an error due to a forward model used as inverse model")
+	info := pcic.RGBInfo{}
+	_, err = info.CameraModel()
+	assert.Error(t, err, "We expect an error due to a missing inverse model")
+}
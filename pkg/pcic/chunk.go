@@ -1,7 +1,9 @@
 package pcic
 
 import (
+	"bytes"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"time"
@@ -13,11 +15,6 @@ type (
 	ChunkOption func(c *Chunk)
 )
 
-// The known Chunk Types
-const (
-	RADIAL_DISTANCE_NOISE ChunkType = 105
-)
-
 // The known Data Formats
 const (
 	FORMAT_8U  DataFormat = 0 /* 8bit unsigned integer */
@@ -50,7 +47,8 @@ type Chunk struct {
 	statusCode    uint32     // Conveys the status of the device default: 0
 	timestampSec  uint32     // The timestamp seconds part
 	timestampNSec uint32     // The timestamp nano seconds part
-	metadata      string     // The JSON meta data is always {} for v2 chunks
+	metadata      string     // The raw JSON meta data of v3 chunks, empty when not present
+	headerTail    []byte     // The v3 header bytes after the meta data, e.g. padding or vendor data
 	data          []byte     // The data the chunk describes
 }
 
@@ -70,6 +68,22 @@ const (
 	offsetOfData          = 0x0030
 )
 
+// The header extension of version 3 chunks
+const (
+	offsetOfMetadataLength = 0x0030 // The length of the JSON meta data
+	offsetOfMetadata       = 0x0034 // The JSON meta data
+)
+
+const (
+	emptyMetadata string = "{}"
+)
+
+// The header versions which can be marshalled
+const (
+	headerVersionV2 = 2
+	headerVersionV3 = 3
+)
+
 const (
 	MaxSupportedChunkHeaderVersion = 3
 )
@@ -78,8 +92,7 @@ func NewChunk(options ...ChunkOption) *Chunk {
 	chunk := &Chunk{
 		chunkSize:     offsetOfData,
 		headerSize:    offsetOfData,
-		headerVersion: 2,
-		metadata:      "{}",
+		headerVersion: headerVersionV2,
 		data:          []byte{},
 	}
 	// Apply options
@@ -107,6 +120,13 @@ func WithDimension(width, height int, format DataFormat) ChunkOption {
 	}
 }
 
+// WithMetadata creates a version 3 Chunk carrying the given JSON meta data
+func WithMetadata(metadata string) ChunkOption {
+	return func(c *Chunk) {
+		c.SetMetadata(metadata)
+	}
+}
+
 // Type returns the given ChunkType
 func (c *Chunk) Type() ChunkType {
 	return c.chunkType
@@ -148,7 +168,16 @@ func (c *Chunk) SetTimestamp(value time.Time) {
 	c.timestampSec = uint32(value.Unix())
 	seconds := time.Unix(int64(c.timestampSec), 0)
 	c.timestampNSec = uint32(value.UnixNano() - seconds.UnixNano())
-	fmt.Printf("Seconds: %d Nanoseconds: %d\n", c.timestampSec, c.timestampNSec)
+}
+
+// LegacyTimeStamp returns the deprecated micro seconds time stamp of the Chunk
+func (c *Chunk) LegacyTimeStamp() uint32 {
+	return c.timeStamp
+}
+
+// SetLegacyTimeStamp sets the deprecated micro seconds time stamp of the Chunk
+func (c *Chunk) SetLegacyTimeStamp(micros uint32) {
+	c.timeStamp = micros
 }
 
 // Bytes return the data the current Chunk is holding
@@ -156,22 +185,76 @@ func (c *Chunk) Bytes() []byte {
 	return c.data
 }
 
+// HeaderVersion returns the version of the chunk header
+func (c *Chunk) HeaderVersion() uint32 {
+	return c.headerVersion
+}
+
+// Metadata returns the raw JSON meta data of the Chunk
+//
+// Only chunks with a version 3 header carry meta data, for all other
+// chunks the empty JSON object "{}" is returned.
+func (c *Chunk) Metadata() string {
+	if c.metadata == "" {
+		return emptyMetadata
+	}
+	return c.metadata
+}
+
+// SetMetadata sets the raw JSON meta data and upgrades the Chunk to a version 3 header
+func (c *Chunk) SetMetadata(metadata string) {
+	c.headerVersion = headerVersionV3
+	c.metadata = metadata
+	c.headerTail = nil
+	c.headerSize = offsetOfData
+	c.headerSize = c.marshalledHeaderSize()
+	c.chunkSize = c.headerSize + uint32(len(c.data))
+}
+
+// UnmarshalMetadata parses the JSON meta data of the Chunk into v
+func (c *Chunk) UnmarshalMetadata(v any) error {
+	return json.Unmarshal([]byte(c.Metadata()), v)
+}
+
+// marshalledHeaderSize returns the header size required to marshal the Chunk
+//
+// A larger header size of a parsed chunk is kept to preserve its layout.
+func (c *Chunk) marshalledHeaderSize() uint32 {
+	size := uint32(offsetOfData)
+	if c.headerVersion == headerVersionV3 && c.metadata != "" {
+		size = offsetOfMetadata + uint32(len(c.metadata))
+	}
+	if c.headerVersion == headerVersionV3 && c.headerSize > size {
+		size = c.headerSize
+	}
+	return size
+}
+
 // MarshalBinary creates a binary representation of the Chunk
 //
-// The binary representation is encoded in the byte slice
+// The binary representation is encoded in the byte slice. Version 3 chunks
+// keep their header size, meta data and the header bytes following the meta
+// data, so a parsed Chunk marshals to the same bytes it was created from.
 func (c *Chunk) MarshalBinary() (data []byte, err error) {
-	blob := make([]byte, offsetOfData+len(c.data))
+	if c.headerVersion != headerVersionV2 && c.headerVersion != headerVersionV3 {
+		return nil, fmt.Errorf("unable to marshal the chunk header version: %d", c.headerVersion)
+	}
+	if c.metadata != "" && !json.Valid([]byte(c.metadata)) {
+		return nil, errors.New("the chunk meta data is not valid JSON")
+	}
+	headerSize := c.marshalledHeaderSize()
+	blob := make([]byte, int(headerSize)+len(c.data))
 	binary.LittleEndian.PutUint32(
 		blob,
 		uint32(c.chunkType),
 	)
 	binary.LittleEndian.PutUint32(
 		blob[offsetOfSize:offsetOfHeaderSize],
-		c.chunkSize,
+		uint32(len(blob)),
 	)
 	binary.LittleEndian.PutUint32(
 		blob[offsetOfHeaderSize:offsetOfHeaderVersion],
-		c.headerSize,
+		headerSize,
 	)
 	binary.LittleEndian.PutUint32(
 		blob[offsetOfHeaderVersion:offsetOfWidth],
@@ -189,7 +272,10 @@ func (c *Chunk) MarshalBinary() (data []byte, err error) {
 		blob[offsetOfFormat:offsetOfTimeStamp],
 		uint32(c.dataFormat),
 	)
-	// We skip the timestamp for now, it is deprecated
+	binary.LittleEndian.PutUint32(
+		blob[offsetOfTimeStamp:offsetOfFrameCount],
+		c.timeStamp,
+	)
 	binary.LittleEndian.PutUint32(
 		blob[offsetOfFrameCount:offsetOfStatusCode],
 		c.frameCount,
@@ -207,8 +293,17 @@ func (c *Chunk) MarshalBinary() (data []byte, err error) {
 		c.timestampNSec,
 	)
 
+	if headerSize >= offsetOfMetadata {
+		binary.LittleEndian.PutUint32(
+			blob[offsetOfMetadataLength:offsetOfMetadata],
+			uint32(len(c.metadata)),
+		)
+		copy(blob[offsetOfMetadata:], c.metadata)
+		copy(blob[offsetOfMetadata+len(c.metadata):headerSize], c.headerTail)
+	}
+
 	// copy the data we keep
-	copy(blob[offsetOfData:], c.data)
+	copy(blob[headerSize:], c.data)
 	return blob, nil
 }
 
@@ -217,10 +312,33 @@ func (c *Chunk) MarshalBinary() (data []byte, err error) {
 // It copies the data from the input slice to comply with the BinaryUnmarshaler
 // interface.
 func (c *Chunk) UnmarshalBinary(data []byte) error {
+	src, err := c.unmarshalHeader(data)
+	if err != nil {
+		return err
+	}
+	// Copy the data to this chunk
+	c.data = make([]byte, len(src))
+	copy(c.data, src)
+	c.headerTail = bytes.Clone(c.headerTail)
+	return nil
+}
+
+// unmarshalView parses the Chunk without copying the data, the Chunk
+// references the input slice and is only valid as long as the input is.
+func (c *Chunk) unmarshalView(data []byte) error {
+	src, err := c.unmarshalHeader(data)
+	if err != nil {
+		return err
+	}
+	c.data = src[:len(src):len(src)]
+	return nil
+}
 
+// unmarshalHeader parses the header fields and returns the data section of the Chunk
+func (c *Chunk) unmarshalHeader(data []byte) ([]byte, error) {
 	dataLen := uint32(len(data))
 	if dataLen < offsetOfData {
-		return errors.New("unable to parse an empty input")
+		return nil, errors.New("unable to parse an empty input")
 	}
 	c.chunkType = ChunkType(
 		binary.LittleEndian.Uint32(data[offsetOfType : offsetOfType+4]),
@@ -229,10 +347,10 @@ func (c *Chunk) UnmarshalBinary(data []byte) error {
 		data[offsetOfSize : offsetOfSize+4],
 	)
 	if c.chunkSize < offsetOfData {
-		return fmt.Errorf("the chunk size needs to be at minimum: %d", offsetOfData)
+		return nil, fmt.Errorf("the chunk size needs to be at minimum: %d", offsetOfData)
 	}
 	if c.chunkSize > dataLen {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"the chunk size expected is: %d but the data is only: %d",
 			c.chunkSize,
 			dataLen,
@@ -242,13 +360,20 @@ func (c *Chunk) UnmarshalBinary(data []byte) error {
 		data[offsetOfHeaderSize : offsetOfHeaderSize+4],
 	)
 	if c.headerSize < offsetOfData {
-		return fmt.Errorf("the chunk header size needs to be at minimum: %d", offsetOfData)
+		return nil, fmt.Errorf("the chunk header size needs to be at minimum: %d", offsetOfData)
+	}
+	if c.headerSize > c.chunkSize {
+		return nil, fmt.Errorf(
+			"the chunk header size: %d exceeds the chunk size: %d",
+			c.headerSize,
+			c.chunkSize,
+		)
 	}
 	c.headerVersion = binary.LittleEndian.Uint32(
 		data[offsetOfHeaderVersion : offsetOfHeaderVersion+4],
 	)
-	if c.headerVersion == 2 && c.headerSize > offsetOfData {
-		return fmt.Errorf(
+	if c.headerVersion == headerVersionV2 && c.headerSize > offsetOfData {
+		return nil, fmt.Errorf(
 			"the chunk header size expected is: %d but the expected maximum is only: %d",
 			c.headerSize,
 			offsetOfData,
@@ -256,7 +381,7 @@ func (c *Chunk) UnmarshalBinary(data []byte) error {
 	}
 
 	if c.headerVersion == 0 || c.headerVersion > MaxSupportedChunkHeaderVersion {
-		return fmt.Errorf("invalid chunk header version given: %d maximum supported version: %d",
+		return nil, fmt.Errorf("invalid chunk header version given: %d maximum supported version: %d",
 			c.headerVersion,
 			MaxSupportedChunkHeaderVersion,
 		)
@@ -267,17 +392,17 @@ func (c *Chunk) UnmarshalBinary(data []byte) error {
 	c.dataHeight = binary.LittleEndian.Uint32(
 		data[offsetOfHeight : offsetOfHeight+4],
 	)
-	if (c.dataHeight * c.dataWidth) > (dataLen - c.headerSize) {
-		return fmt.Errorf(
+	if (c.dataHeight * c.dataWidth) > (c.chunkSize - c.headerSize) {
+		return nil, fmt.Errorf(
 			"the length of the given data can not be smaller than the given data width and height multiplied",
 		)
 	}
 	c.dataFormat = DataFormat(binary.LittleEndian.Uint32(
 		data[offsetOfFormat : offsetOfFormat+4],
 	))
-	if c.dataFormat > FORMAT_MAX {
-		return fmt.Errorf(
-			"the the data format does not match the range of valid data formats [0,%d]",
+	if c.dataFormat >= FORMAT_MAX {
+		return nil, fmt.Errorf(
+			"the the data format does not match the range of valid data formats [0,%d)",
 			FORMAT_MAX,
 		)
 	}
@@ -302,21 +427,76 @@ func (c *Chunk) UnmarshalBinary(data []byte) error {
 		data[offsetOfTimeStampNsec : offsetOfTimeStampNsec+4],
 	)
 
-	// Copy the data to this chunk
-	src := data[offsetOfData : offsetOfData+(c.chunkSize-c.headerSize)]
-	c.data = make([]byte, len(src))
-	copy(c.data, src)
+	metadata, err := metadataParser(data[:c.headerSize], c.headerVersion)
+	if err != nil {
+		return nil, err
+	}
+	c.metadata = metadata
+	c.headerTail = nil
+	if tail := offsetOfMetadata + uint32(len(metadata)); c.headerVersion == headerVersionV3 && c.headerSize > tail {
+		c.headerTail = data[tail:c.headerSize:c.headerSize]
+	}
+
+	src := data[c.headerSize:c.chunkSize]
 
-	if (c.dataWidth * c.dataHeight * byteSizeLUT[c.dataFormat]) != uint32(len(c.data)) {
-		return fmt.Errorf(
+	if (c.dataWidth * c.dataHeight * byteSizeLUT[c.dataFormat]) != uint32(len(src)) {
+		return nil, fmt.Errorf(
 			"a size mismatch detected, width (%d) times height (%d) does not equal the data size (%d) format: %d",
 			c.dataWidth,
 			c.dataHeight,
-			len(c.data),
+			len(src),
 			c.dataFormat,
 		)
 
 	}
 
-	return nil
+	return src, nil
+}
+
+// metadataParser extracts the JSON meta data from the header extension of version 3 chunks
+func metadataParser(header []byte, version uint32) (string, error) {
+	if version < headerVersionV3 || len(header) == offsetOfData {
+		return "", nil
+	}
+	if len(header) < offsetOfMetadata {
+		return "", fmt.Errorf(
+			"the chunk header size: %d is too small for the meta data length field",
+			len(header),
+		)
+	}
+	length := binary.LittleEndian.Uint32(
+		header[offsetOfMetadataLength:offsetOfMetadata],
+	)
+	if uint64(offsetOfMetadata)+uint64(length) > uint64(len(header)) {
+		return "", fmt.Errorf(
+			"the meta data length: %d exceeds the chunk header size: %d",
+			length,
+			len(header),
+		)
+	}
+	if length == 0 {
+		return "", nil
+	}
+	metadata := header[offsetOfMetadata : offsetOfMetadata+length]
+	if !json.Valid(metadata) {
+		return "", errors.New("the chunk meta data is not valid JSON")
+	}
+	return string(metadata), nil
+}
+
+// MetadataField returns the value of the top level meta data key decoded into T
+func MetadataField[T any](c *Chunk, key string) (T, error) {
+	var value T
+	fields := map[string]json.RawMessage{}
+	if err := c.UnmarshalMetadata(&fields); err != nil {
+		return value, err
+	}
+	raw, ok := fields[key]
+	if !ok {
+		return value, fmt.Errorf("the chunk %s has no meta data field: %s", c.chunkType, key)
+	}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, fmt.Errorf("the meta data field %s can not be decoded: %w", key, err)
+	}
+	return value, nil
 }
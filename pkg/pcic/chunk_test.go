@@ -3,6 +3,8 @@ package pcic_test
 import (
 	"bytes"
 	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
 	"math/rand"
 	"testing"
 	"time"
@@ -476,3 +478,182 @@ func TestCloneWithData(t *testing.T) {
 		"A data mismatch detected",
 	)
 }
+
+// v3Chunk creates a binary version 3 chunk carrying the meta data and the payload
+func v3Chunk(metadata string, payload []byte) []byte {
+	headerSize := 0x34 + len(metadata)
+	data := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(data[0x00:], uint32(pcic.O3R_RESULT_JSON))
+	binary.LittleEndian.PutUint32(data[0x04:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[0x08:], uint32(headerSize))
+	binary.LittleEndian.PutUint32(data[0x0C:], 3)
+	binary.LittleEndian.PutUint32(data[0x10:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[0x14:], 1)
+	binary.LittleEndian.PutUint32(data[0x18:], uint32(pcic.FORMAT_8U))
+	binary.LittleEndian.PutUint32(data[0x30:], uint32(len(metadata)))
+	copy(data[0x34:], metadata)
+	copy(data[headerSize:], payload)
+	return data
+}
+
+func TestUnmarshalMetadata(t *testing.T) {
+	c := pcic.NewChunk()
+	assert.NoError(t,
+		c.UnmarshalBinary(v3Chunk(`{"sensor": "port2", "exposure": [30, 400]}`, []byte("{}"))),
+		"A successful parse expected",
+	)
+	assert.Equal(t, uint32(3), c.HeaderVersion(), "A header version mismatch occurred")
+	assert.Equal(t,
+		`{"sensor": "port2", "exposure": [30, 400]}`,
+		c.Metadata(),
+		"A meta data mismatch occurred",
+	)
+	assert.Equal(t, []byte("{}"), c.Bytes(), "We expect the data to start after the meta data")
+
+	sensor, err := pcic.MetadataField[string](c, "sensor")
+	assert.NoError(t, err, "We expect no error while reading the meta data field")
+	assert.Equal(t, "port2", sensor, "A meta data field mismatch occurred")
+	exposure, err := pcic.MetadataField[[]int](c, "exposure")
+	assert.NoError(t, err, "We expect no error while reading the meta data field")
+	assert.Equal(t, []int{30, 400}, exposure, "A meta data field mismatch occurred")
+	_, err = pcic.MetadataField[int](c, "sensor")
+	assert.Error(t, err, "We expect an error due to a type mismatch")
+	_, err = pcic.MetadataField[int](c, "missing")
+	assert.Error(t, err, "We expect an error due to a missing field")
+
+	var doc struct{ Sensor string }
+	assert.NoError(t, c.UnmarshalMetadata(&doc), "We expect no error while decoding the meta data")
+	assert.Equal(t, "port2", doc.Sensor, "A meta data mismatch occurred")
+}
+
+func TestUnmarshalEmptyMetadata(t *testing.T) {
+	c := pcic.NewChunk()
+	assert.NoError(t, c.UnmarshalBinary(v3Chunk("", []byte("{}"))), "A successful parse expected")
+	assert.Equal(t, "{}", c.Metadata(), "We expect an empty JSON object")
+
+	chunk := pcic.NewChunk()
+	assert.Equal(t, "{}", chunk.Metadata(), "We expect an empty JSON object")
+}
+
+func TestUnmarshalMalformedMetadata(t *testing.T) {
+	c := pcic.NewChunk()
+	assert.Error(t,
+		c.UnmarshalBinary(v3Chunk(`{"sensor": `, []byte("{}"))),
+		"An error expected, due to invalid JSON",
+	)
+	data := v3Chunk(`{}`, []byte("{}"))
+	binary.LittleEndian.PutUint32(data[0x30:], 0x100)
+	assert.Error(t,
+		c.UnmarshalBinary(data),
+		"An error expected, due to a meta data length exceeding the header",
+	)
+	data = v3Chunk(`{}`, []byte("{}"))
+	binary.LittleEndian.PutUint32(data[0x08:], uint32(len(data)+1))
+	assert.Error(t,
+		c.UnmarshalBinary(data),
+		"An error expected, due to a header size exceeding the chunk size",
+	)
+}
+
+// randomChunk creates the binary representation of a random chunk with a version 2 or 3 header
+func randomChunk(r *rand.Rand) []byte {
+	version := uint32(2 + r.Intn(2))
+	metadata := ""
+	padding := 0
+	headerSize := 0x30
+	if version == 3 && r.Intn(4) > 0 {
+		metadata = fmt.Sprintf(`{"id": %d, "name": %q}`, r.Int63(), fmt.Sprint(r.Uint32()))
+		padding = r.Intn(8)
+		headerSize = 0x34 + len(metadata) + padding
+	}
+	format := pcic.DataFormat(r.Intn(int(pcic.FORMAT_MAX)))
+	width, height := r.Intn(16), r.Intn(16)
+	payload := make([]byte, width*height*int([]int{1, 1, 2, 2, 4, 4, 4, 8, 8}[format]))
+	r.Read(payload)
+	data := make([]byte, headerSize+len(payload))
+	binary.LittleEndian.PutUint32(data[0x00:], r.Uint32())
+	binary.LittleEndian.PutUint32(data[0x04:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[0x08:], uint32(headerSize))
+	binary.LittleEndian.PutUint32(data[0x0C:], version)
+	binary.LittleEndian.PutUint32(data[0x10:], uint32(width))
+	binary.LittleEndian.PutUint32(data[0x14:], uint32(height))
+	binary.LittleEndian.PutUint32(data[0x18:], uint32(format))
+	for offset := 0x1C; offset < 0x30; offset += 4 {
+		binary.LittleEndian.PutUint32(data[offset:], r.Uint32())
+	}
+	if metadata != "" {
+		binary.LittleEndian.PutUint32(data[0x30:], uint32(len(metadata)))
+		copy(data[0x34:], metadata)
+		// The padding may carry vendor data
+		r.Read(data[0x34+len(metadata) : headerSize])
+	}
+	copy(data[headerSize:], payload)
+	return data
+}
+
+func TestBinaryRoundtripProperty(t *testing.T) {
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for i := 0; i < 1000; i++ {
+		original := randomChunk(r)
+		chunk := pcic.NewChunk()
+		if !assert.NoError(t, chunk.UnmarshalBinary(original), "A successful parse expected") {
+			return
+		}
+		data, err := chunk.MarshalBinary()
+		assert.NoError(t, err, "No error expected when marshalling to binary")
+		if !assert.Equal(t, original, data, "We expect the same bytes after the round trip") {
+			return
+		}
+		assert.Equal(t, chunk.Size(), len(data), "A size mismatch occurred")
+	}
+}
+
+func TestHeaderTailRoundtrip(t *testing.T) {
+	vendor := []byte{0xde, 0xad, 0xbe, 0xef, 0x01, 0x02}
+	for _, metadata := range []string{"", `{"id": 1}`} {
+		data := v3Chunk(metadata, []byte{1, 2, 3})
+		headerSize := 0x34 + len(metadata)
+		data = append(data[:headerSize], append(append([]byte{}, vendor...), data[headerSize:]...)...)
+		binary.LittleEndian.PutUint32(data[0x04:], uint32(len(data)))
+		binary.LittleEndian.PutUint32(data[0x08:], uint32(headerSize+len(vendor)))
+
+		chunk := pcic.NewChunk()
+		assert.NoError(t, chunk.UnmarshalBinary(data), "A successful parse expected")
+		assert.Equal(t, []byte{1, 2, 3}, chunk.Bytes(), "A data mismatch occurred")
+		marshalled, err := chunk.MarshalBinary()
+		assert.NoError(t, err, "No error expected when marshalling to binary")
+		assert.Equal(t, data, marshalled, "We expect the vendor data to be kept")
+
+		// New meta data changes the layout, the vendor data is dropped
+		chunk.SetMetadata(`{"id": 2}`)
+		marshalled, err = chunk.MarshalBinary()
+		assert.NoError(t, err, "No error expected when marshalling to binary")
+		assert.Equal(t, 0x34+len(`{"id": 2}`)+3, len(marshalled), "A size mismatch occurred")
+	}
+}
+
+func TestMetadataRoundtrip(t *testing.T) {
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(pcic.O3R_RESULT_JSON),
+		pcic.WithDimension(3, 1, pcic.FORMAT_8U),
+		pcic.WithMetadata(`{"sensor": "port2"}`),
+	)
+	chunk.SetLegacyTimeStamp(123456)
+	chunk.SetTimestamp(time.Unix(1700000000, 42))
+	assert.Equal(t, uint32(3), chunk.HeaderVersion(), "We expect a version 3 header")
+	assert.Equal(t, 0x34+len(`{"sensor": "port2"}`)+3, chunk.Size(), "A size mismatch occurred")
+
+	data, err := chunk.MarshalBinary()
+	assert.NoError(t, err, "No error expected when marshalling to binary")
+	assert.Equal(t, chunk.Size(), len(data), "A size mismatch occurred")
+	clone := pcic.NewChunk()
+	assert.NoError(t, clone.UnmarshalBinary(data), "No error expected when unmarshalling from binary")
+	assert.Equal(t, chunk.Metadata(), clone.Metadata(), "A meta data mismatch occurred")
+	assert.Equal(t, uint32(123456), clone.LegacyTimeStamp(), "A legacy time stamp mismatch occurred")
+	assert.Equal(t, chunk.TimeStamp(), clone.TimeStamp(), "A time stamp mismatch occurred")
+	assert.Equal(t, chunk.Bytes(), clone.Bytes(), "A data mismatch occurred")
+
+	chunk.SetMetadata(`{`)
+	_, err = chunk.MarshalBinary()
+	assert.Error(t, err, "An error expected, due to invalid JSON")
+}
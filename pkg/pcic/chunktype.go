@@ -0,0 +1,153 @@
+package pcic
+
+import "fmt"
+
+// The known Chunk Types of the O3R
+const (
+	RADIAL_DISTANCE_IMAGE                    ChunkType = 100  /* The radial distance of each pixel */
+	NORM_AMPLITUDE_IMAGE                     ChunkType = 101  /* The amplitude normalized by the exposure time */
+	AMPLITUDE_IMAGE                          ChunkType = 103  /* The raw amplitude */
+	GRAYSCALE_IMAGE                          ChunkType = 104  /* The grayscale image */
+	RADIAL_DISTANCE_NOISE                    ChunkType = 105  /* The noise estimation of the radial distance */
+	REFLECTIVITY                             ChunkType = 107  /* The reflectivity estimation */
+	CARTESIAN_X_COMPONENT                    ChunkType = 200  /* The X component of the point cloud */
+	CARTESIAN_Y_COMPONENT                    ChunkType = 201  /* The Y component of the point cloud */
+	CARTESIAN_Z_COMPONENT                    ChunkType = 202  /* The Z component of the point cloud */
+	CARTESIAN_ALL                            ChunkType = 203  /* The interleaved X, Y and Z components */
+	UNIT_VECTOR_ALL                          ChunkType = 223  /* The interleaved unit vectors of each pixel */
+	MONOCHROM_2D_12BIT                       ChunkType = 250  /* A 12 bit monochrome 2D image */
+	MONOCHROM_2D                             ChunkType = 251  /* A monochrome 2D image */
+	JPEG_IMAGE                               ChunkType = 260  /* A JPEG encoded 2D image */
+	CONFIDENCE_IMAGE                         ChunkType = 300  /* The confidence bit field of each pixel */
+	DIAGNOSTIC                               ChunkType = 302  /* The diagnostic data */
+	JSON_DIAGNOSTIC                          ChunkType = 305  /* The diagnostic data in JSON format */
+	EXTRINSIC_CALIB                          ChunkType = 400  /* The extrinsic calibration */
+	INTRINSIC_CALIB                          ChunkType = 401  /* The intrinsic calibration */
+	INVERSE_INTRINSIC_CALIBRATION            ChunkType = 402  /* The inverse intrinsic calibration */
+	TOF_INFO                                 ChunkType = 420  /* The calibration and acquisition state of the 3D data */
+	RGB_INFO                                 ChunkType = 421  /* The calibration and acquisition state of the 2D data */
+	JSON_MODEL                               ChunkType = 500  /* The JSON model of the application */
+	ALGO_DEBUG                               ChunkType = 900  /* The algorithm debug data */
+	O3R_ODS_OCCUPANCY_GRID                   ChunkType = 1200 /* The ODS occupancy grid */
+	O3R_ODS_INFO                             ChunkType = 1201 /* The ODS zone information */
+	O3R_RESULT_JSON                          ChunkType = 1300 /* The application result in JSON format */
+	O3R_RESULT_ARRAY2D                       ChunkType = 1301 /* The application result as 2D array */
+	O3R_RESULT_IMU                           ChunkType = 1302 /* The IMU samples */
+	O3R_ODS_RENDERED_ZONES                   ChunkType = 1303 /* The rendered ODS zones */
+	O3R_ODS_FLAGS                            ChunkType = 1304 /* The ODS flags */
+	O3R_MCC_LIVE_IMAGE                       ChunkType = 1305 /* The MCC live image */
+	O3R_MCC_MOTION_IMAGE                     ChunkType = 1306 /* The MCC motion image */
+	O3R_MCC_STATIC_IMAGE                     ChunkType = 1307 /* The MCC static image */
+	O3R_MCC_RESULT                           ChunkType = 1308 /* The MCC result */
+	O3R_ODS_POLAR_OCC_GRID                   ChunkType = 1309 /* The ODS polar occupancy grid */
+	O3R_ODS_EXTRINSIC_CALIBRATION_CORRECTION ChunkType = 1310 /* The ODS extrinsic calibration correction */
+)
+
+// chunkTypeInfo describes the name and the expected data format of a ChunkType
+type chunkTypeInfo struct {
+	name   string
+	format DataFormat
+}
+
+var chunkTypes = map[ChunkType]chunkTypeInfo{
+	RADIAL_DISTANCE_IMAGE:                    {"RADIAL_DISTANCE_IMAGE", FORMAT_16U},
+	NORM_AMPLITUDE_IMAGE:                     {"NORM_AMPLITUDE_IMAGE", FORMAT_16U},
+	AMPLITUDE_IMAGE:                          {"AMPLITUDE_IMAGE", FORMAT_16U},
+	GRAYSCALE_IMAGE:                          {"GRAYSCALE_IMAGE", FORMAT_16U},
+	RADIAL_DISTANCE_NOISE:                    {"RADIAL_DISTANCE_NOISE", FORMAT_16U},
+	REFLECTIVITY:                             {"REFLECTIVITY", FORMAT_8U},
+	CARTESIAN_X_COMPONENT:                    {"CARTESIAN_X_COMPONENT", FORMAT_32F},
+	CARTESIAN_Y_COMPONENT:                    {"CARTESIAN_Y_COMPONENT", FORMAT_32F},
+	CARTESIAN_Z_COMPONENT:                    {"CARTESIAN_Z_COMPONENT", FORMAT_32F},
+	CARTESIAN_ALL:                            {"CARTESIAN_ALL", FORMAT_32F},
+	UNIT_VECTOR_ALL:                          {"UNIT_VECTOR_ALL", FORMAT_32F},
+	MONOCHROM_2D_12BIT:                       {"MONOCHROM_2D_12BIT", FORMAT_16U},
+	MONOCHROM_2D:                             {"MONOCHROM_2D", FORMAT_8U},
+	JPEG_IMAGE:                               {"JPEG_IMAGE", FORMAT_8U},
+	CONFIDENCE_IMAGE:                         {"CONFIDENCE_IMAGE", FORMAT_16U},
+	DIAGNOSTIC:                               {"DIAGNOSTIC", FORMAT_8U},
+	JSON_DIAGNOSTIC:                          {"JSON_DIAGNOSTIC", FORMAT_8U},
+	EXTRINSIC_CALIB:                          {"EXTRINSIC_CALIB", FORMAT_32F},
+	INTRINSIC_CALIB:                          {"INTRINSIC_CALIB", FORMAT_32F},
+	INVERSE_INTRINSIC_CALIBRATION:            {"INVERSE_INTRINSIC_CALIBRATION", FORMAT_32F},
+	TOF_INFO:                                 {"TOF_INFO", FORMAT_8U},
+	RGB_INFO:                                 {"RGB_INFO", FORMAT_8U},
+	JSON_MODEL:                               {"JSON_MODEL", FORMAT_8U},
+	ALGO_DEBUG:                               {"ALGO_DEBUG", FORMAT_8U},
+	O3R_ODS_OCCUPANCY_GRID:                   {"O3R_ODS_OCCUPANCY_GRID", FORMAT_8U},
+	O3R_ODS_INFO:                             {"O3R_ODS_INFO", FORMAT_8U},
+	O3R_RESULT_JSON:                          {"O3R_RESULT_JSON", FORMAT_8U},
+	O3R_RESULT_ARRAY2D:                       {"O3R_RESULT_ARRAY2D", FORMAT_8U},
+	O3R_RESULT_IMU:                           {"O3R_RESULT_IMU", FORMAT_8U},
+	O3R_ODS_RENDERED_ZONES:                   {"O3R_ODS_RENDERED_ZONES", FORMAT_8U},
+	O3R_ODS_FLAGS:                            {"O3R_ODS_FLAGS", FORMAT_8U},
+	O3R_MCC_LIVE_IMAGE:                       {"O3R_MCC_LIVE_IMAGE", FORMAT_8U},
+	O3R_MCC_MOTION_IMAGE:                     {"O3R_MCC_MOTION_IMAGE", FORMAT_8U},
+	O3R_MCC_STATIC_IMAGE:                     {"O3R_MCC_STATIC_IMAGE", FORMAT_8U},
+	O3R_MCC_RESULT:                           {"O3R_MCC_RESULT", FORMAT_8U},
+	O3R_ODS_POLAR_OCC_GRID:                   {"O3R_ODS_POLAR_OCC_GRID", FORMAT_8U},
+	O3R_ODS_EXTRINSIC_CALIBRATION_CORRECTION: {"O3R_ODS_EXTRINSIC_CALIBRATION_CORRECTION", FORMAT_8U},
+}
+
+var dataFormatNames = [FORMAT_MAX]string{
+	"FORMAT_8U", "FORMAT_8S", "FORMAT_16U", "FORMAT_16S",
+	"FORMAT_32U", "FORMAT_32S", "FORMAT_32F", "FORMAT_64U", "FORMAT_64F",
+}
+
+// String returns the name of the ChunkType, e.g. "RADIAL_DISTANCE_IMAGE"
+//
+// Unknown chunk types are represented by their numeric value, e.g. "ChunkType(42)".
+func (t ChunkType) String() string {
+	if info, ok := chunkTypes[t]; ok {
+		return info.name
+	}
+	return fmt.Sprintf("ChunkType(%d)", uint32(t))
+}
+
+// Known returns true in case the ChunkType is part of the registry
+func (t ChunkType) Known() bool {
+	_, ok := chunkTypes[t]
+	return ok
+}
+
+// DefaultFormat returns the data format the device uses for the ChunkType.
+//
+// The second return value is false in case the ChunkType is unknown.
+func (t ChunkType) DefaultFormat() (DataFormat, bool) {
+	info, ok := chunkTypes[t]
+	return info.format, ok
+}
+
+// ParseChunkType returns the ChunkType for the given name, e.g. "TOF_INFO"
+func ParseChunkType(name string) (ChunkType, error) {
+	for chunkType, info := range chunkTypes {
+		if info.name == name {
+			return chunkType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown chunk type: %q", name)
+}
+
+// String returns the name of the DataFormat, e.g. "FORMAT_32F"
+func (f DataFormat) String() string {
+	if f < FORMAT_MAX {
+		return dataFormatNames[f]
+	}
+	return fmt.Sprintf("DataFormat(%d)", uint32(f))
+}
+
+// ValidateFormat checks whether the data format of the Chunk matches the
+// default data format of its ChunkType. Chunks of an unknown type are
+// always considered valid.
+func (c *Chunk) ValidateFormat() error {
+	expected, ok := c.chunkType.DefaultFormat()
+	if !ok || expected == c.dataFormat {
+		return nil
+	}
+	return fmt.Errorf(
+		"the chunk %s has the data format %s but %s is expected",
+		c.chunkType,
+		c.dataFormat,
+		expected,
+	)
+}
@@ -0,0 +1,66 @@
+package pcic_test
+
+import (
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkTypeString(t *testing.T) {
+	assert.Equal(t, "RADIAL_DISTANCE_NOISE", pcic.RADIAL_DISTANCE_NOISE.String())
+	assert.Equal(t, "TOF_INFO", pcic.TOF_INFO.String())
+	assert.Equal(t, "ChunkType(42)", pcic.ChunkType(42).String())
+	assert.True(t, pcic.CONFIDENCE_IMAGE.Known(), "We expect the confidence image to be known")
+	assert.False(t, pcic.ChunkType(42).Known(), "We expect the chunk type 42 to be unknown")
+}
+
+func TestParseChunkType(t *testing.T) {
+	for _, chunkType := range []pcic.ChunkType{
+		pcic.RADIAL_DISTANCE_IMAGE,
+		pcic.UNIT_VECTOR_ALL,
+		pcic.O3R_RESULT_IMU,
+	} {
+		parsed, err := pcic.ParseChunkType(chunkType.String())
+		assert.NoError(t, err, "We expect no error while parsing %s", chunkType)
+		assert.Equal(t, chunkType, parsed, "A chunk type mismatch occurred")
+	}
+	_, err := pcic.ParseChunkType("NO_SUCH_CHUNK")
+	assert.Error(t, err, "We expect an error for an unknown name")
+}
+
+func TestDataFormatString(t *testing.T) {
+	assert.Equal(t, "FORMAT_8U", pcic.FORMAT_8U.String())
+	assert.Equal(t, "FORMAT_64F", pcic.FORMAT_64F.String())
+	assert.Equal(t, "DataFormat(9)", pcic.FORMAT_MAX.String())
+}
+
+func TestValidateFormat(t *testing.T) {
+	format, ok := pcic.CONFIDENCE_IMAGE.DefaultFormat()
+	assert.True(t, ok, "We expect the confidence image to have a default format")
+	assert.Equal(t, pcic.FORMAT_16U, format, "A data format mismatch occurred")
+	_, ok = pcic.ChunkType(42).DefaultFormat()
+	assert.False(t, ok, "We expect no default format for an unknown chunk type")
+
+	assert.NoError(t,
+		pcic.NewChunk(
+			pcic.WithChunkType(pcic.CONFIDENCE_IMAGE),
+			pcic.WithDimension(2, 2, pcic.FORMAT_16U),
+		).ValidateFormat(),
+		"We expect the format to match",
+	)
+	assert.Error(t,
+		pcic.NewChunk(
+			pcic.WithChunkType(pcic.CONFIDENCE_IMAGE),
+			pcic.WithDimension(2, 2, pcic.FORMAT_32F),
+		).ValidateFormat(),
+		"We expect a format mismatch",
+	)
+	assert.NoError(t,
+		pcic.NewChunk(
+			pcic.WithChunkType(pcic.ChunkType(42)),
+			pcic.WithDimension(2, 2, pcic.FORMAT_32F),
+		).ValidateFormat(),
+		"We expect unknown chunk types to be valid",
+	)
+}
@@ -0,0 +1,225 @@
+package pcic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// The range of tickets used for commands, the tickets below are
+// reserved for the asynchronous messages sent by the device.
+const (
+	minCommandTicket int = 100
+	maxCommandTicket int = 9999
+)
+
+// defaultTicketExpiry is the time a canceled command keeps its ticket by default
+const defaultTicketExpiry = 30 * time.Second
+
+// ErrNoTicketAvailable is returned when all command tickets are in flight
+var ErrNoTicketAvailable = errors.New("no free ticket available, too many commands in flight")
+
+// Send transmits a PCIC command to the device and waits for the matching reply.
+//
+// A unique ticket is allocated for each command, which allows several commands
+// to be in flight at the same time. The reply is delivered by ProcessIncomming,
+// so the receive loop has to run concurrently to Send. Asynchronous results,
+// errors and notifications are still passed to the MessageHandler in the meantime.
+// The returned reply contains the content of the answer without ticket and trailer.
+func (p *PCICClient) Send(ctx context.Context, command []byte) ([]byte, error) {
+	if p.writer == nil {
+		return nil, errors.New("no bufio.Writer provided, please instantiate the object")
+	}
+	ticket, reply, err := p.allocateTicket()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.writeCommand(ticket, command); err != nil {
+		p.releaseTicket(ticket)
+		return nil, err
+	}
+	start := time.Now()
+	p.logger.Debug("PCIC command sent",
+		slog.Int("ticket", ticket),
+		slog.Int("size", len(command)),
+	)
+	select {
+	case answer := <-reply:
+		p.logger.Debug("PCIC reply received",
+			slog.Int("ticket", ticket),
+			slog.Int("size", len(answer)),
+			slog.Duration("duration", time.Since(start)),
+		)
+		return answer, nil
+	case <-ctx.Done():
+		// The ticket stays reserved until the late reply arrives or the
+		// expiry is reached, this way it is not mistaken for the reply of
+		// a new command and it does not leak in case the reply never comes.
+		p.abandonTicket(ticket)
+		return nil, ctx.Err()
+	case <-p.done:
+		return nil, ErrClientClosed
+	}
+}
+
+// writeCommand writes a single command with the given ticket to the device
+func (p *PCICClient) writeCommand(ticket int, command []byte) error {
+	p.writeMutex.Lock()
+	defer p.writeMutex.Unlock()
+	_, err := fmt.Fprintf(p.writer,
+		"%04dL%09d\r\n%04d%s\r\n",
+		ticket,
+		ticketFieldLength+len(command)+delimiterFieldLength,
+		ticket,
+		command,
+	)
+	if err != nil {
+		return err
+	}
+	return p.writer.Flush()
+}
+
+// allocateTicket reserves the next free ticket and registers the reply channel
+func (p *PCICClient) allocateTicket() (int, chan []byte, error) {
+	p.ticketMutex.Lock()
+	defer p.ticketMutex.Unlock()
+	now := time.Now()
+	for i := minCommandTicket; i <= maxCommandTicket; i++ {
+		ticket := p.nextTicket
+		p.nextTicket++
+		if p.nextTicket > maxCommandTicket {
+			p.nextTicket = minCommandTicket
+		}
+		if _, inUse := p.pending[ticket]; inUse {
+			continue
+		}
+		if expires, abandoned := p.abandoned[ticket]; abandoned {
+			if now.Before(expires) {
+				continue
+			}
+			delete(p.abandoned, ticket)
+		}
+		reply := make(chan []byte, 1)
+		p.pending[ticket] = reply
+		return ticket, reply, nil
+	}
+	return 0, nil, ErrNoTicketAvailable
+}
+
+// releaseTicket frees the ticket without delivering a reply
+func (p *PCICClient) releaseTicket(ticket int) {
+	p.ticketMutex.Lock()
+	defer p.ticketMutex.Unlock()
+	delete(p.pending, ticket)
+}
+
+// abandonTicket keeps the ticket of a canceled command reserved until the expiry
+func (p *PCICClient) abandonTicket(ticket int) {
+	p.ticketMutex.Lock()
+	defer p.ticketMutex.Unlock()
+	if _, ok := p.pending[ticket]; !ok {
+		// The reply arrived in the meantime
+		return
+	}
+	delete(p.pending, ticket)
+	p.abandoned[ticket] = time.Now().Add(p.expiry)
+}
+
+// dispatchReply hands the content over to the command waiting for the ticket.
+//
+// It returns false in case no command is waiting for the given ticket.
+func (p *PCICClient) dispatchReply(ticketField []byte, content []byte) bool {
+	ticket, err := strconv.Atoi(string(ticketField))
+	if err != nil {
+		return false
+	}
+	p.ticketMutex.Lock()
+	defer p.ticketMutex.Unlock()
+	if _, ok := p.abandoned[ticket]; ok {
+		// The late reply of a canceled command is dropped
+		delete(p.abandoned, ticket)
+		return true
+	}
+	reply, ok := p.pending[ticket]
+	if !ok {
+		return false
+	}
+	delete(p.pending, ticket)
+	reply <- content
+	return true
+}
+
+// The replies of the device to a command
+const (
+	replySuccess       string = "*"
+	replyFailed        string = "!"
+	replyInvalidFormat string = "?"
+)
+
+var (
+	// ErrCommandFailed is the cause of a CommandError when the device was unable to execute the command
+	ErrCommandFailed = errors.New("the device failed to execute the command")
+	// ErrCommandInvalid is the cause of a CommandError when the device did not understand the command
+	ErrCommandInvalid = errors.New("the command is invalid or not supported by the device")
+)
+
+// CommandError is returned when the device does not acknowledge a command
+type CommandError struct {
+	Command string // The command identifier, e.g. "p"
+	Reply   string // The reply received from the device, e.g. "!"
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("the command %q was not acknowledged, the device replied: %q", e.Command, e.Reply)
+}
+
+// Unwrap returns ErrCommandFailed or ErrCommandInvalid depending on the reply
+func (e *CommandError) Unwrap() error {
+	switch e.Reply {
+	case replyFailed:
+		return ErrCommandFailed
+	case replyInvalidFormat:
+		return ErrCommandInvalid
+	}
+	return nil
+}
+
+// SetResultOutput enables (p1) or disables (p0) the asynchronous result output
+func (p *PCICClient) SetResultOutput(ctx context.Context, enabled bool) error {
+	command := "p0"
+	if enabled {
+		command = "p1"
+	}
+	return p.sendAcknowledged(ctx, "p", []byte(command))
+}
+
+// Trigger sends a software trigger (t) to the device
+//
+// The resulting frame is delivered asynchronously to the MessageHandler.
+func (p *PCICClient) Trigger(ctx context.Context) error {
+	return p.sendAcknowledged(ctx, "t", []byte("t"))
+}
+
+// SetOutputSchema uploads a custom PCIC output schema (c) to the device
+//
+// The schema is the JSON layouter description of the asynchronous output.
+func (p *PCICClient) SetOutputSchema(ctx context.Context, schema string) error {
+	command := fmt.Sprintf("c%09d%s", len(schema), schema)
+	return p.sendAcknowledged(ctx, "c", []byte(command))
+}
+
+// sendAcknowledged sends the command and returns a CommandError
+// in case the device does not reply with the success indicator.
+func (p *PCICClient) sendAcknowledged(ctx context.Context, id string, command []byte) error {
+	reply, err := p.Send(ctx, command)
+	if err != nil {
+		return err
+	}
+	if string(reply) != replySuccess {
+		return &CommandError{Command: id, Reply: string(reply)}
+	}
+	return nil
+}
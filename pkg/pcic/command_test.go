@@ -0,0 +1,257 @@
+package pcic_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// readCommand reads a single PCIC command as the device would do
+func readCommand(reader *bufio.Reader) (string, string, error) {
+	var ticket, secondTicket string
+	var length int
+	if _, err := fmt.Fscanf(reader, "%4sL%09d\r\n", &ticket, &length); err != nil {
+		return "", "", err
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return "", "", err
+	}
+	secondTicket = string(content[:4])
+	if ticket != secondTicket {
+		return "", "", fmt.Errorf("ticket mismatch %s != %s", ticket, secondTicket)
+	}
+	return ticket, string(content[4 : len(content)-2]), nil
+}
+
+// writeMessage writes a single PCIC message as the device would do
+func writeMessage(writer io.Writer, ticket, content string) error {
+	_, err := fmt.Fprintf(writer,
+		"%sL%09d\r\n%s%s\r\n",
+		ticket,
+		len(content)+6,
+		ticket,
+		content,
+	)
+	return err
+}
+
+func newPipeClient(t *testing.T) (*pcic.PCICClient, net.Conn) {
+	host, device := net.Pipe()
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(
+			bufio.NewReadWriter(bufio.NewReader(host), bufio.NewWriter(host)),
+		),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	t.Cleanup(func() {
+		host.Close()
+		device.Close()
+	})
+	return p, device
+}
+
+func receiveLoop(p *pcic.PCICClient, handler pcic.MessageHandler) {
+	for {
+		if err := p.ProcessIncomming(handler); err != nil {
+			return
+		}
+	}
+}
+
+func TestSendCommand(t *testing.T) {
+	p, device := newPipeClient(t)
+	go receiveLoop(p, &PCICAsyncReceiver{})
+	go func() {
+		reader := bufio.NewReader(device)
+		ticket, content, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		// Send an async result in between to ensure it does not interfere
+		_ = writeMessage(device, "0000", "starstop")
+		_ = writeMessage(device, ticket, "*"+content)
+	}()
+	reply, err := p.Send(context.Background(), []byte("p1"))
+	assert.NoError(t, err, "We expect no error while sending a command")
+	assert.Equal(t, "*p1", string(reply), "A reply mismatch occurred")
+}
+
+func TestSendConcurrentCommands(t *testing.T) {
+	const inFlight = 5
+	p, device := newPipeClient(t)
+	go receiveLoop(p, &PCICAsyncReceiver{})
+	go func() {
+		reader := bufio.NewReader(device)
+		tickets := make([]string, 0, inFlight)
+		contents := make([]string, 0, inFlight)
+		for i := 0; i < inFlight; i++ {
+			ticket, content, err := readCommand(reader)
+			if err != nil {
+				return
+			}
+			tickets = append(tickets, ticket)
+			contents = append(contents, content)
+		}
+		// Reply in the reverse order
+		for i := inFlight - 1; i >= 0; i-- {
+			_ = writeMessage(device, tickets[i], contents[i])
+		}
+	}()
+	var wg sync.WaitGroup
+	for i := 0; i < inFlight; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			command := fmt.Sprintf("command-%d", i)
+			reply, err := p.Send(context.Background(), []byte(command))
+			assert.NoError(t, err, "We expect no error while sending a command")
+			assert.Equal(t, command, string(reply), "The reply does not match the command")
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestSendCancel(t *testing.T) {
+	p, device := newPipeClient(t)
+	go receiveLoop(p, &PCICAsyncReceiver{})
+	go func() {
+		// Consume the command but never reply
+		_, _, _ = readCommand(bufio.NewReader(device))
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := p.Send(ctx, []byte("t"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded, "We expect the deadline to be exceeded")
+}
+
+// abandonAll cancels as many commands as there are tickets without any reply
+func abandonAll(t *testing.T, p *pcic.PCICClient) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i := 100; i <= 9999; i++ {
+		_, err := p.Send(ctx, []byte("t"))
+		if !assert.ErrorIs(t, err, context.Canceled, "We expect the command to be canceled") {
+			return
+		}
+	}
+}
+
+func TestSendCancelWithoutReply(t *testing.T) {
+	device := bufio.NewReader(strings.NewReader("0100L000000007\r\n0100*\r\n"))
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(device, bufio.NewWriter(io.Discard))),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	abandonAll(t, p)
+	_, err = p.Send(context.Background(), []byte("t"))
+	assert.ErrorIs(t, err, pcic.ErrNoTicketAvailable, "We expect the tickets to be reserved before the expiry")
+	assert.NoError(t,
+		p.ProcessIncomming(&PCICAsyncReceiver{}),
+		"We expect the late reply of a canceled command to be dropped",
+	)
+
+	p, err = pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(nil, bufio.NewWriter(io.Discard))),
+		pcic.WithTicketExpiry(time.Millisecond),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	abandonAll(t, p)
+	time.Sleep(5 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.Send(ctx, []byte("t"))
+	assert.ErrorIs(t, err, context.Canceled, "We expect the expired tickets to be reused")
+}
+
+func TestSendWithoutWriter(t *testing.T) {
+	p, err := pcic.NewPCICClient()
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	_, err = p.Send(context.Background(), []byte("p1"))
+	assert.Error(t, err, "We expect an error when no writer is provided")
+}
+
+// replyOnce answers a single command with the given reply and
+// provides the received command content via the returned channel.
+func replyOnce(device net.Conn, reply string) <-chan string {
+	received := make(chan string, 1)
+	go func() {
+		ticket, content, err := readCommand(bufio.NewReader(device))
+		if err != nil {
+			close(received)
+			return
+		}
+		received <- content
+		_ = writeMessage(device, ticket, reply)
+	}()
+	return received
+}
+
+func TestSetResultOutput(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		p, device := newPipeClient(t)
+		go receiveLoop(p, &PCICAsyncReceiver{})
+		received := replyOnce(device, "*")
+		assert.NoError(t,
+			p.SetResultOutput(context.Background(), enabled),
+			"We expect no error when the device acknowledges the command",
+		)
+		expected := "p0"
+		if enabled {
+			expected = "p1"
+		}
+		assert.Equal(t, expected, <-received, "A command mismatch occurred")
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	p, device := newPipeClient(t)
+	go receiveLoop(p, &PCICAsyncReceiver{})
+	received := replyOnce(device, "*")
+	assert.NoError(t,
+		p.Trigger(context.Background()),
+		"We expect no error when the device acknowledges the trigger",
+	)
+	assert.Equal(t, "t", <-received, "A command mismatch occurred")
+}
+
+func TestSetOutputSchema(t *testing.T) {
+	schema := `{"layouter": "flexible", "format": {"dataencoding": "ascii"}, "elements": []}`
+	p, device := newPipeClient(t)
+	go receiveLoop(p, &PCICAsyncReceiver{})
+	received := replyOnce(device, "*")
+	assert.NoError(t,
+		p.SetOutputSchema(context.Background(), schema),
+		"We expect no error when the device acknowledges the schema",
+	)
+	assert.Equal(t,
+		fmt.Sprintf("c%09d%s", len(schema), schema),
+		<-received,
+		"A command mismatch occurred",
+	)
+}
+
+func TestCommandErrors(t *testing.T) {
+	for reply, cause := range map[string]error{
+		"!": pcic.ErrCommandFailed,
+		"?": pcic.ErrCommandInvalid,
+	} {
+		p, device := newPipeClient(t)
+		go receiveLoop(p, &PCICAsyncReceiver{})
+		replyOnce(device, reply)
+		err := p.Trigger(context.Background())
+		var cmdErr *pcic.CommandError
+		assert.ErrorAs(t, err, &cmdErr, "We expect a CommandError")
+		assert.ErrorIs(t, err, cause, "The cause of the error does not match")
+		assert.Equal(t, reply, cmdErr.Reply, "A reply mismatch occurred")
+	}
+}
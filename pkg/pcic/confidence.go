@@ -0,0 +1,118 @@
+package pcic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfidenceFlags is the bit field of a pixel in the CONFIDENCE_IMAGE chunk
+type ConfidenceFlags uint16
+
+// The flags of the confidence bit field
+//
+// Source: the ifm O3R documentation of the confidence image, see also the ifm3d
+// examples which treat a pixel as valid when bit 0 is cleared. Only bit 0 is
+// specified there as the validity flag, it is the only bit ValidityMask and
+// PointCloudFromFrame rely on by default. The reasons in bits 1 to 7 are not
+// backed by a public specification, they depend on the firmware and have to
+// be confirmed against the documentation of the installed firmware before
+// they are used for filtering, e.g. with Mask or WithRejectedFlags.
+const (
+	CONFIDENCE_INVALID          ConfidenceFlags = 1 << 0 /* The pixel is invalid, set whenever one of the reasons applies */
+	CONFIDENCE_SATURATED        ConfidenceFlags = 1 << 1 /* The pixel is saturated */
+	CONFIDENCE_LOW_AMPLITUDE    ConfidenceFlags = 1 << 2 /* The amplitude is below the threshold */
+	CONFIDENCE_OUT_OF_RANGE     ConfidenceFlags = 1 << 3 /* The distance is outside of the measurement range */
+	CONFIDENCE_HIGH_NOISE       ConfidenceFlags = 1 << 4 /* The distance noise is above the threshold */
+	CONFIDENCE_LOW_REFLECTIVITY ConfidenceFlags = 1 << 5 /* The reflectivity is below the threshold */
+	CONFIDENCE_MIXED_PIXEL      ConfidenceFlags = 1 << 6 /* The pixel mixes foreground and background */
+	CONFIDENCE_SUSPECT          ConfidenceFlags = 1 << 7 /* The pixel is suspected to be an outlier */
+)
+
+var confidenceFlagNames = []struct {
+	flag ConfidenceFlags
+	name string
+}{
+	{CONFIDENCE_INVALID, "INVALID"},
+	{CONFIDENCE_SATURATED, "SATURATED"},
+	{CONFIDENCE_LOW_AMPLITUDE, "LOW_AMPLITUDE"},
+	{CONFIDENCE_OUT_OF_RANGE, "OUT_OF_RANGE"},
+	{CONFIDENCE_HIGH_NOISE, "HIGH_NOISE"},
+	{CONFIDENCE_LOW_REFLECTIVITY, "LOW_REFLECTIVITY"},
+	{CONFIDENCE_MIXED_PIXEL, "MIXED_PIXEL"},
+	{CONFIDENCE_SUSPECT, "SUSPECT"},
+}
+
+// Has reports whether all of the given flags are set
+func (f ConfidenceFlags) Has(flags ConfidenceFlags) bool {
+	return f&flags == flags
+}
+
+// Valid reports whether the pixel is not marked as invalid
+func (f ConfidenceFlags) Valid() bool {
+	return f&CONFIDENCE_INVALID == 0
+}
+
+// String returns the names of the set flags separated by "|", unknown bits are given in hex
+func (f ConfidenceFlags) String() string {
+	if f == 0 {
+		return "VALID"
+	}
+	names := []string{}
+	for _, n := range confidenceFlagNames {
+		if f&n.flag != 0 {
+			names = append(names, n.name)
+			f &^= n.flag
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("0x%04x", uint16(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// ConfidenceImage is the decoded CONFIDENCE_IMAGE chunk
+type ConfidenceImage struct {
+	img *Image[uint16]
+}
+
+// ConfidenceFromChunk decodes the CONFIDENCE_IMAGE chunk
+func ConfidenceFromChunk(c *Chunk) (*ConfidenceImage, error) {
+	if c.chunkType != CONFIDENCE_IMAGE {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, CONFIDENCE_IMAGE)
+	}
+	img, err := ImageFromChunk[uint16](c)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfidenceImage{img: img}, nil
+}
+
+// Width returns the number of pixels in a row
+func (ci *ConfidenceImage) Width() int {
+	return ci.img.width
+}
+
+// Height returns the number of rows
+func (ci *ConfidenceImage) Height() int {
+	return ci.img.height
+}
+
+// At returns the flags of the pixel at the column x and the row y, it panics if the position is out of range
+func (ci *ConfidenceImage) At(x, y int) ConfidenceFlags {
+	return ConfidenceFlags(ci.img.At(x, y))
+}
+
+// Mask returns for each pixel in row major order whether none of the rejected flags is set
+func (ci *ConfidenceImage) Mask(rejected ConfidenceFlags) []bool {
+	mask := make([]bool, len(ci.img.pix))
+	for i, v := range ci.img.pix {
+		mask[i] = ConfidenceFlags(v)&rejected == 0
+	}
+	return mask
+}
+
+// ValidityMask returns for each pixel in row major order whether it is valid,
+// only the documented CONFIDENCE_INVALID bit is taken into account
+func (ci *ConfidenceImage) ValidityMask() []bool {
+	return ci.Mask(CONFIDENCE_INVALID)
+}
@@ -0,0 +1,48 @@
+package pcic_test
+
+import (
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfidenceFlags(t *testing.T) {
+	flags := pcic.CONFIDENCE_INVALID | pcic.CONFIDENCE_SATURATED
+	assert.True(t, flags.Has(pcic.CONFIDENCE_SATURATED), "We expect the flag to be set")
+	assert.True(t, flags.Has(flags), "We expect all flags to be set")
+	assert.False(t, flags.Has(pcic.CONFIDENCE_SATURATED|pcic.CONFIDENCE_MIXED_PIXEL))
+	assert.False(t, flags.Valid(), "We expect the pixel to be invalid")
+	assert.True(t, pcic.CONFIDENCE_SUSPECT.Valid(), "We expect a suspect pixel to be valid")
+
+	assert.Equal(t, "VALID", pcic.ConfidenceFlags(0).String())
+	assert.Equal(t, "INVALID|SATURATED", flags.String())
+	assert.Equal(t, "MIXED_PIXEL|0x0100", (pcic.CONFIDENCE_MIXED_PIXEL | 0x100).String())
+}
+
+func TestConfidenceFromChunk(t *testing.T) {
+	confidence, err := pcic.ConfidenceFromChunk(uint16Chunk(pcic.CONFIDENCE_IMAGE, 2, 2, []uint16{
+		0x00, 0x03,
+		0x80, 0x45,
+	}))
+	assert.NoError(t, err, "We expect no error while decoding the confidence image")
+	assert.Equal(t, 2, confidence.Width(), "A width mismatch occurred")
+	assert.Equal(t, 2, confidence.Height(), "A height mismatch occurred")
+	assert.Equal(t, pcic.CONFIDENCE_SUSPECT, confidence.At(0, 1), "A flag mismatch occurred")
+	assert.Equal(t,
+		pcic.CONFIDENCE_INVALID|pcic.CONFIDENCE_LOW_AMPLITUDE|pcic.CONFIDENCE_MIXED_PIXEL,
+		confidence.At(1, 1),
+		"A flag mismatch occurred",
+	)
+	assert.Equal(t, []bool{true, false, true, false}, confidence.ValidityMask())
+	assert.Equal(t,
+		[]bool{true, false, false, false},
+		confidence.Mask(pcic.CONFIDENCE_INVALID|pcic.CONFIDENCE_SUSPECT),
+		"A mask mismatch occurred",
+	)
+
+	_, err = pcic.ConfidenceFromChunk(uint16Chunk(pcic.AMPLITUDE_IMAGE, 1, 1, []uint16{0}))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+	_, err = pcic.ConfidenceFromChunk(typedFloat32Chunk(pcic.CONFIDENCE_IMAGE, 1, 1, []float32{0}))
+	assert.Error(t, err, "We expect an error due to a data format mismatch")
+}
@@ -1,5 +1,138 @@
 package pcic
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Frame holds the chunks of a single PCIC result message
 type Frame struct {
 	Chunks []Chunk
+	buffer *messageBuffer // The pooled buffer the chunks reference, nil if the chunks own their data
+	lease  uint64         // The lease of the buffer the Frame was created with, see Release
+}
+
+// ChunkByType returns the first Chunk of the given type
+//
+// The returned Chunk references the Frame, it is only valid as long as the
+// Frame is.
+func (f *Frame) ChunkByType(chunkType ChunkType) (*Chunk, bool) {
+	for i := range f.Chunks {
+		if f.Chunks[i].chunkType == chunkType {
+			return &f.Chunks[i], true
+		}
+	}
+	return nil, false
+}
+
+// Has reports whether the Frame contains a Chunk for each of the given types
+func (f *Frame) Has(types ...ChunkType) bool {
+	for _, chunkType := range types {
+		if _, ok := f.ChunkByType(chunkType); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// FrameCount returns the frame count of the first Chunk, zero for an empty Frame
+func (f *Frame) FrameCount() uint32 {
+	if len(f.Chunks) == 0 {
+		return 0
+	}
+	return f.Chunks[0].frameCount
+}
+
+// TimeStamp returns the time stamp of the first Chunk, the zero time for an empty Frame
+func (f *Frame) TimeStamp() time.Time {
+	if len(f.Chunks) == 0 {
+		return time.Time{}
+	}
+	return f.Chunks[0].TimeStamp()
+}
+
+// CheckFrameCount returns an error in case the chunks do not share the same frame count
+func (f *Frame) CheckFrameCount() error {
+	count := f.FrameCount()
+	for i := range f.Chunks {
+		if f.Chunks[i].frameCount != count {
+			return fmt.Errorf(
+				"the chunk %s has the frame count %d but the frame count %d is expected",
+				f.Chunks[i].chunkType,
+				f.Chunks[i].frameCount,
+				count,
+			)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary creates the content of a result message including the start and the end marker
+func (f *Frame) MarshalBinary() ([]byte, error) {
+	size := len(startMarker) + len(endMarker)
+	for i := range f.Chunks {
+		size += f.Chunks[i].Size()
+	}
+	data := make([]byte, 0, size)
+	data = append(data, startMarker...)
+	for i := range f.Chunks {
+		chunk, err := f.Chunks[i].MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal the chunk %s: %w", f.Chunks[i].chunkType, err)
+		}
+		data = append(data, chunk...)
+	}
+	return append(data, endMarker...), nil
+}
+
+// UnmarshalBinary parses the content of a result message including the start and the end marker
+//
+// It copies the data from the input slice to comply with the BinaryUnmarshaler
+// interface.
+func (f *Frame) UnmarshalBinary(data []byte) error {
+	if len(data) < len(startMarker)+len(endMarker) {
+		return errors.New("the frame is too short")
+	}
+	if !bytes.HasPrefix(data, []byte(startMarker)) || !bytes.HasSuffix(data, []byte(endMarker)) {
+		return errors.New("the frame is not enclosed by the start and the end marker")
+	}
+	content := data[len(startMarker) : len(data)-len(endMarker)]
+	chunks, err := chunkContentParser(content, nil, (*Chunk).UnmarshalBinary)
+	if err != nil {
+		return err
+	}
+	*f = Frame{Chunks: chunks}
+	return nil
+}
+
+// Release hands the pooled buffer of the Frame back for reuse.
+//
+// Frames received by a PCICClient created WithBufferPool reference a pooled
+// buffer. A Frame is a value, all copies of it share the same buffer and
+// Release ends the use of the buffer for all of them: once any copy was
+// released neither of the copies nor the data of their chunks must be used.
+// Only the first Release of a buffer returns it to the pool, releasing another
+// copy or releasing twice is a no-op. For all other frames Release is a no-op.
+func (f *Frame) Release() {
+	if f.buffer == nil {
+		return
+	}
+	f.buffer.release(f.lease)
+	f.buffer = nil
+	f.Chunks = nil
+}
+
+// Clone creates a deep copy of the Frame which owns the data of its chunks.
+//
+// This allows to keep a pooled Frame beyond the call to Release.
+func (f *Frame) Clone() Frame {
+	clone := Frame{Chunks: make([]Chunk, len(f.Chunks))}
+	for i, c := range f.Chunks {
+		clone.Chunks[i] = c
+		clone.Chunks[i].data = bytes.Clone(c.data)
+		clone.Chunks[i].headerTail = bytes.Clone(c.headerTail)
+	}
+	return clone
 }
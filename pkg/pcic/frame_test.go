@@ -0,0 +1,92 @@
+package pcic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func testFrame() pcic.Frame {
+	frame := pcic.Frame{Chunks: []pcic.Chunk{
+		*pcic.NewChunk(
+			pcic.WithChunkType(pcic.RADIAL_DISTANCE_IMAGE),
+			pcic.WithDimension(2, 2, pcic.FORMAT_16U),
+		),
+		*pcic.NewChunk(
+			pcic.WithChunkType(pcic.O3R_RESULT_JSON),
+			pcic.WithDimension(2, 1, pcic.FORMAT_8U),
+			pcic.WithMetadata(`{"sensor": "port2"}`),
+		),
+	}}
+	for i := range frame.Chunks {
+		frame.Chunks[i].SetFrameCount(42)
+		frame.Chunks[i].SetTimestamp(time.Unix(1700000000, 1000))
+	}
+	copy(frame.Chunks[1].Bytes(), "{}")
+	return frame
+}
+
+func TestFrameChunkByType(t *testing.T) {
+	frame := testFrame()
+	chunk, ok := frame.ChunkByType(pcic.O3R_RESULT_JSON)
+	assert.True(t, ok, "We expect the chunk to be found")
+	assert.Equal(t, pcic.O3R_RESULT_JSON, chunk.Type(), "A chunk type mismatch occurred")
+	_, ok = frame.ChunkByType(pcic.AMPLITUDE_IMAGE)
+	assert.False(t, ok, "We expect the chunk to be missing")
+
+	assert.True(t, frame.Has(pcic.RADIAL_DISTANCE_IMAGE, pcic.O3R_RESULT_JSON))
+	assert.True(t, frame.Has(), "We expect an empty set of types to be present")
+	assert.False(t, frame.Has(pcic.RADIAL_DISTANCE_IMAGE, pcic.AMPLITUDE_IMAGE))
+}
+
+func TestFrameCountAndTimeStamp(t *testing.T) {
+	frame := testFrame()
+	assert.Equal(t, uint32(42), frame.FrameCount(), "A frame count mismatch occurred")
+	assert.Equal(t, time.Unix(1700000000, 1000), frame.TimeStamp(), "A time stamp mismatch occurred")
+	assert.NoError(t, frame.CheckFrameCount(), "We expect a consistent frame count")
+
+	frame.Chunks[1].SetFrameCount(43)
+	assert.Error(t, frame.CheckFrameCount(), "We expect an error due to a frame count mismatch")
+
+	empty := pcic.Frame{}
+	assert.Equal(t, uint32(0), empty.FrameCount(), "We expect a zero frame count")
+	assert.True(t, empty.TimeStamp().IsZero(), "We expect a zero time stamp")
+	assert.NoError(t, empty.CheckFrameCount(), "We expect an empty frame to be consistent")
+}
+
+func TestFrameRoundtrip(t *testing.T) {
+	frame := testFrame()
+	data, err := frame.MarshalBinary()
+	assert.NoError(t, err, "No error expected when marshalling to binary")
+	assert.Equal(t, "star", string(data[:4]), "A start marker mismatch occurred")
+	assert.Equal(t, "stop", string(data[len(data)-4:]), "A stop marker mismatch occurred")
+
+	clone := pcic.Frame{}
+	assert.NoError(t, clone.UnmarshalBinary(data), "No error expected when unmarshalling from binary")
+	assert.Equal(t, len(frame.Chunks), len(clone.Chunks), "A chunk count mismatch occurred")
+	chunk, ok := clone.ChunkByType(pcic.O3R_RESULT_JSON)
+	assert.True(t, ok, "We expect the chunk to be found")
+	assert.Equal(t, `{"sensor": "port2"}`, chunk.Metadata(), "A meta data mismatch occurred")
+	assert.Equal(t, []byte("{}"), chunk.Bytes(), "A data mismatch occurred")
+
+	again, err := clone.MarshalBinary()
+	assert.NoError(t, err, "No error expected when marshalling to binary")
+	assert.Equal(t, data, again, "We expect the same bytes after the round trip")
+
+	empty := pcic.Frame{}
+	data, err = empty.MarshalBinary()
+	assert.NoError(t, err, "No error expected when marshalling to binary")
+	assert.Equal(t, "starstop", string(data), "An empty frame mismatch occurred")
+}
+
+func TestFrameUnmarshalMalformed(t *testing.T) {
+	frame := pcic.Frame{}
+	for _, data := range []string{"", "star", "stopstar", "starXstop", "startstop"} {
+		assert.Error(t,
+			frame.UnmarshalBinary([]byte(data)),
+			"We expect an error while parsing: %q", data,
+		)
+	}
+}
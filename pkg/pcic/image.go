@@ -0,0 +1,285 @@
+package pcic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+type (
+	// Pixel is the set of element types an Image can hold, one for each DataFormat
+	Pixel interface {
+		uint8 | int8 | uint16 | int16 | uint32 | int32 | float32 | uint64 | float64
+	}
+
+	// Image is a typed, row major view of the data of a Chunk
+	Image[T Pixel] struct {
+		width  int
+		height int
+		pix    []T
+	}
+)
+
+// NewImage creates an Image of the given dimension with all pixels set to zero
+func NewImage[T Pixel](width, height int) *Image[T] {
+	return &Image[T]{
+		width:  width,
+		height: height,
+		pix:    make([]T, width*height),
+	}
+}
+
+// FormatOf returns the DataFormat which corresponds to the pixel type T
+func FormatOf[T Pixel]() DataFormat {
+	var zero T
+	switch any(zero).(type) {
+	case uint8:
+		return FORMAT_8U
+	case int8:
+		return FORMAT_8S
+	case uint16:
+		return FORMAT_16U
+	case int16:
+		return FORMAT_16S
+	case uint32:
+		return FORMAT_32U
+	case int32:
+		return FORMAT_32S
+	case float32:
+		return FORMAT_32F
+	case uint64:
+		return FORMAT_64U
+	}
+	return FORMAT_64F
+}
+
+// ImageFromChunk decodes the little endian data of the Chunk into an Image.
+//
+// The DataFormat of the Chunk has to match the pixel type T, use
+// ConvertedImageFromChunk in case a conversion is required.
+func ImageFromChunk[T Pixel](c *Chunk) (*Image[T], error) {
+	if format := FormatOf[T](); c.dataFormat != format {
+		return nil, fmt.Errorf(
+			"the chunk %s has the data format %s but the image requires %s",
+			c.chunkType,
+			c.dataFormat,
+			format,
+		)
+	}
+	img := NewImage[T](int(c.dataWidth), int(c.dataHeight))
+	if len(c.data) != len(img.pix)*int(byteSizeLUT[c.dataFormat]) {
+		return nil, fmt.Errorf(
+			"the data size (%d) does not match the dimension %dx%d",
+			len(c.data),
+			c.dataWidth,
+			c.dataHeight,
+		)
+	}
+	decodePixels(img.pix, c.data)
+	return img, nil
+}
+
+// decodePixels fills pix with the little endian encoded values of data
+func decodePixels[T Pixel](pix []T, data []byte) {
+	switch p := any(pix).(type) {
+	case []uint8:
+		copy(p, data)
+	case []int8:
+		for i := range p {
+			p[i] = int8(data[i])
+		}
+	case []uint16:
+		for i := range p {
+			p[i] = binary.LittleEndian.Uint16(data[2*i:])
+		}
+	case []int16:
+		for i := range p {
+			p[i] = int16(binary.LittleEndian.Uint16(data[2*i:]))
+		}
+	case []uint32:
+		for i := range p {
+			p[i] = binary.LittleEndian.Uint32(data[4*i:])
+		}
+	case []int32:
+		for i := range p {
+			p[i] = int32(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	case []float32:
+		for i := range p {
+			p[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+		}
+	case []uint64:
+		for i := range p {
+			p[i] = binary.LittleEndian.Uint64(data[8*i:])
+		}
+	case []float64:
+		for i := range p {
+			p[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	}
+}
+
+// ConvertedImageFromChunk decodes the Chunk and converts each pixel to the type T.
+//
+// Values which do not fit into T saturate, see ConvertImage.
+func ConvertedImageFromChunk[T Pixel](c *Chunk) (*Image[T], error) {
+	switch c.dataFormat {
+	case FORMAT_8U:
+		return convertChunk[T, uint8](c)
+	case FORMAT_8S:
+		return convertChunk[T, int8](c)
+	case FORMAT_16U:
+		return convertChunk[T, uint16](c)
+	case FORMAT_16S:
+		return convertChunk[T, int16](c)
+	case FORMAT_32U:
+		return convertChunk[T, uint32](c)
+	case FORMAT_32S:
+		return convertChunk[T, int32](c)
+	case FORMAT_32F:
+		return convertChunk[T, float32](c)
+	case FORMAT_64U:
+		return convertChunk[T, uint64](c)
+	case FORMAT_64F:
+		return convertChunk[T, float64](c)
+	}
+	return nil, fmt.Errorf("unsupported data format: %s", c.dataFormat)
+}
+
+func convertChunk[T, S Pixel](c *Chunk) (*Image[T], error) {
+	src, err := ImageFromChunk[S](c)
+	if err != nil {
+		return nil, err
+	}
+	return ConvertImage[T](src), nil
+}
+
+// ConvertImage creates a copy of the Image with each pixel converted to the type T
+//
+// Values which do not fit into T saturate at the minimum or the maximum of T,
+// e.g. 70000 and +Inf become 65535 when converted to uint16 and -1 becomes 0.
+// Floating point values are rounded toward zero and NaN becomes 0 when
+// converted to an integer type.
+func ConvertImage[T, S Pixel](src *Image[S]) *Image[T] {
+	dst := NewImage[T](src.width, src.height)
+	target, source := FormatOf[T](), FormatOf[S]()
+	if isFloatFormat(target) {
+		// Floating point values saturate at ±Inf by themselves
+		for i, v := range src.pix {
+			dst.pix[i] = T(v)
+		}
+		return dst
+	}
+	minimum, maximum := integerRange(target)
+	switch {
+	case isFloatFormat(source):
+		for i, v := range src.pix {
+			f := float64(v)
+			switch {
+			case f != f:
+				dst.pix[i] = 0
+			case f <= float64(minimum):
+				dst.pix[i] = T(minimum)
+			case f >= float64(maximum):
+				// float64(maximum) is rounded up for 64 bit types, so the
+				// remaining values are in range
+				dst.pix[i] = T(maximum)
+			default:
+				dst.pix[i] = T(f)
+			}
+		}
+	case isSignedFormat(source):
+		for i, v := range src.pix {
+			value := int64(v)
+			switch {
+			case value < minimum:
+				dst.pix[i] = T(minimum)
+			case value > 0 && uint64(value) > maximum:
+				dst.pix[i] = T(maximum)
+			default:
+				dst.pix[i] = T(value)
+			}
+		}
+	default:
+		for i, v := range src.pix {
+			if value := uint64(v); value > maximum {
+				dst.pix[i] = T(maximum)
+			} else {
+				dst.pix[i] = T(value)
+			}
+		}
+	}
+	return dst
+}
+
+func isFloatFormat(format DataFormat) bool {
+	return format == FORMAT_32F || format == FORMAT_64F
+}
+
+func isSignedFormat(format DataFormat) bool {
+	return format == FORMAT_8S || format == FORMAT_16S || format == FORMAT_32S
+}
+
+// integerRange returns the minimum and the maximum value of an integer format
+func integerRange(format DataFormat) (int64, uint64) {
+	switch format {
+	case FORMAT_8U:
+		return 0, math.MaxUint8
+	case FORMAT_8S:
+		return math.MinInt8, math.MaxInt8
+	case FORMAT_16U:
+		return 0, math.MaxUint16
+	case FORMAT_16S:
+		return math.MinInt16, math.MaxInt16
+	case FORMAT_32U:
+		return 0, math.MaxUint32
+	case FORMAT_32S:
+		return math.MinInt32, math.MaxInt32
+	}
+	return 0, math.MaxUint64
+}
+
+// Width returns the number of pixels in a row
+func (img *Image[T]) Width() int {
+	return img.width
+}
+
+// Height returns the number of rows
+func (img *Image[T]) Height() int {
+	return img.height
+}
+
+// Format returns the DataFormat of the pixels
+func (img *Image[T]) Format() DataFormat {
+	return FormatOf[T]()
+}
+
+// At returns the pixel at the column x and the row y, it panics if the position is out of range
+func (img *Image[T]) At(x, y int) T {
+	return img.pix[img.offset(x, y)]
+}
+
+// Set changes the pixel at the column x and the row y, it panics if the position is out of range
+func (img *Image[T]) Set(x, y int, value T) {
+	img.pix[img.offset(x, y)] = value
+}
+
+// Row returns the pixels of the row y, the slice shares the memory with the Image
+func (img *Image[T]) Row(y int) []T {
+	if y < 0 || y >= img.height {
+		panic(fmt.Sprintf("pcic: row %d out of range [0,%d)", y, img.height))
+	}
+	return img.pix[y*img.width : (y+1)*img.width]
+}
+
+// Pix returns all pixels in row major order, the slice shares the memory with the Image
+func (img *Image[T]) Pix() []T {
+	return img.pix
+}
+
+func (img *Image[T]) offset(x, y int) int {
+	if x < 0 || x >= img.width || y < 0 || y >= img.height {
+		panic(fmt.Sprintf("pcic: position (%d,%d) out of range %dx%d", x, y, img.width, img.height))
+	}
+	return y*img.width + x
+}
@@ -0,0 +1,119 @@
+package pcic_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func float32Chunk(width, height int, values []float32) *pcic.Chunk {
+	return typedFloat32Chunk(pcic.CARTESIAN_X_COMPONENT, width, height, values)
+}
+
+func typedFloat32Chunk(chunkType pcic.ChunkType, width, height int, values []float32) *pcic.Chunk {
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(chunkType),
+		pcic.WithDimension(width, height, pcic.FORMAT_32F),
+	)
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(chunk.Bytes()[4*i:], math.Float32bits(v))
+	}
+	return chunk
+}
+
+func TestImageFromChunk(t *testing.T) {
+	chunk := float32Chunk(3, 2, []float32{0, 1.5, 2, 3, 4, -5.25})
+	img, err := pcic.ImageFromChunk[float32](chunk)
+	assert.NoError(t, err, "We expect no error while creating the image")
+	assert.Equal(t, 3, img.Width(), "A width mismatch occurred")
+	assert.Equal(t, 2, img.Height(), "A height mismatch occurred")
+	assert.Equal(t, pcic.FORMAT_32F, img.Format(), "A format mismatch occurred")
+	assert.Equal(t, float32(1.5), img.At(1, 0), "A pixel mismatch occurred")
+	assert.Equal(t, float32(-5.25), img.At(2, 1), "A pixel mismatch occurred")
+	assert.Equal(t, []float32{3, 4, -5.25}, img.Row(1), "A row mismatch occurred")
+	img.Set(0, 0, 42)
+	assert.Equal(t, float32(42), img.Pix()[0], "A pixel mismatch occurred")
+	assert.Panics(t, func() { img.At(3, 0) }, "We expect a panic when out of range")
+	assert.Panics(t, func() { img.Row(2) }, "We expect a panic when out of range")
+
+	_, err = pcic.ImageFromChunk[uint16](chunk)
+	assert.Error(t, err, "We expect an error due to a format mismatch")
+}
+
+func TestImageFromChunkIntegerFormats(t *testing.T) {
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(pcic.RADIAL_DISTANCE_IMAGE),
+		pcic.WithDimension(2, 1, pcic.FORMAT_16U),
+	)
+	binary.LittleEndian.PutUint16(chunk.Bytes(), 0x1234)
+	binary.LittleEndian.PutUint16(chunk.Bytes()[2:], 0xFFFF)
+	img, err := pcic.ImageFromChunk[uint16](chunk)
+	assert.NoError(t, err, "We expect no error while creating the image")
+	assert.Equal(t, []uint16{0x1234, 0xFFFF}, img.Pix(), "A pixel mismatch occurred")
+
+	chunk = pcic.NewChunk(pcic.WithDimension(2, 1, pcic.FORMAT_8S))
+	chunk.Bytes()[0] = 0xFF
+	chunk.Bytes()[1] = 0x7F
+	signed, err := pcic.ImageFromChunk[int8](chunk)
+	assert.NoError(t, err, "We expect no error while creating the image")
+	assert.Equal(t, []int8{-1, 127}, signed.Pix(), "A pixel mismatch occurred")
+}
+
+func TestConvertedImageFromChunk(t *testing.T) {
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(pcic.RADIAL_DISTANCE_IMAGE),
+		pcic.WithDimension(2, 1, pcic.FORMAT_16U),
+	)
+	binary.LittleEndian.PutUint16(chunk.Bytes(), 1000)
+	binary.LittleEndian.PutUint16(chunk.Bytes()[2:], 65535)
+	img, err := pcic.ConvertedImageFromChunk[float32](chunk)
+	assert.NoError(t, err, "We expect no error while converting the image")
+	assert.Equal(t, []float32{1000, 65535}, img.Pix(), "A pixel mismatch occurred")
+
+	back := pcic.ConvertImage[uint16](img)
+	assert.Equal(t, []uint16{1000, 65535}, back.Pix(), "A pixel mismatch occurred")
+	assert.Equal(t, pcic.FORMAT_16U, back.Format(), "A format mismatch occurred")
+}
+
+func TestConvertImageSaturates(t *testing.T) {
+	floats := pcic.NewImage[float32](7, 1)
+	copy(floats.Pix(), []float32{
+		float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1)), 70000, -1, 1234.9, 65535.5,
+	})
+	assert.Equal(t,
+		[]uint16{0, 65535, 0, 65535, 0, 1234, 65535},
+		pcic.ConvertImage[uint16](floats).Pix(),
+		"We expect the values to saturate at the range of uint16",
+	)
+	assert.Equal(t,
+		[]int16{0, 32767, -32768, 32767, -1, 1234, 32767},
+		pcic.ConvertImage[int16](floats).Pix(),
+		"We expect the values to saturate at the range of int16",
+	)
+	assert.Equal(t,
+		[]uint64{0, math.MaxUint64, 0, 70000, 0, 1234, 65535},
+		pcic.ConvertImage[uint64](floats).Pix(),
+		"We expect the values to saturate at the range of uint64",
+	)
+
+	integers := pcic.NewImage[int32](3, 1)
+	copy(integers.Pix(), []int32{-70000, 200, 70000})
+	assert.Equal(t, []uint8{0, 200, 255}, pcic.ConvertImage[uint8](integers).Pix(), "A pixel mismatch occurred")
+	assert.Equal(t, []int8{-128, 127, 127}, pcic.ConvertImage[int8](integers).Pix(), "A pixel mismatch occurred")
+
+	large := pcic.NewImage[uint64](2, 1)
+	copy(large.Pix(), []uint64{math.MaxUint64, 5})
+	assert.Equal(t, []int32{math.MaxInt32, 5}, pcic.ConvertImage[int32](large).Pix(), "A pixel mismatch occurred")
+	assert.Equal(t, []float64{math.MaxUint64, 5}, pcic.ConvertImage[float64](large).Pix(), "A pixel mismatch occurred")
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, pcic.FORMAT_8U, pcic.FormatOf[uint8]())
+	assert.Equal(t, pcic.FORMAT_16S, pcic.FormatOf[int16]())
+	assert.Equal(t, pcic.FORMAT_32S, pcic.FormatOf[int32]())
+	assert.Equal(t, pcic.FORMAT_64U, pcic.FormatOf[uint64]())
+	assert.Equal(t, pcic.FORMAT_64F, pcic.FormatOf[float64]())
+}
@@ -0,0 +1,241 @@
+package pcic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// The layout of the O3R_RESULT_IMU chunk
+const (
+	minIMUResultVersion = 1   // The oldest supported version
+	maxIMUSamples       = 128 // The maximum number of samples in a chunk
+)
+
+// ErrNoIMUSample is returned when no IMU samples enclose the requested time
+var ErrNoIMUSample = errors.New("no IMU samples available for the requested time")
+
+type (
+	// IMUSample is a single measurement of the accelerometer and the gyroscope
+	//
+	// The decoded samples are given in the IMU coordinate system, see IMUResult.UserSamples.
+	IMUSample struct {
+		HardwareTimeStamp uint16     // The time stamp of the IMU hardware
+		TimeStamp         time.Time  // The time the sample was taken
+		Temperature       float32    // The temperature of the IMU in degree Celsius
+		Acceleration      [3]float32 // The linear acceleration along X, Y and Z
+		AngularVelocity   [3]float32 // The angular velocity around X, Y and Z
+	}
+
+	// IMUResult is the decoded O3R_RESULT_IMU chunk
+	IMUResult struct {
+		Version            uint32      // The version of the O3R_RESULT_IMU layout
+		Samples            []IMUSample // The samples ordered by their time stamp
+		ExtrinsicIMUToUser Extrinsic   // The transformation from the IMU to the user coordinate system
+		ExtrinsicIMUToVPU  Extrinsic   // The transformation from the IMU to the VPU coordinate system
+		ReceiveTimeStamp   time.Time   // The time the batch was received from the IMU
+	}
+
+	// Orientation is the tilt derived from the direction of gravity, in radians
+	Orientation struct {
+		Roll  float64 // The rotation around the X axis
+		Pitch float64 // The rotation around the Y axis
+	}
+
+	// imuSampleLayoutV1 is the binary layout of a single IMU sample
+	imuSampleLayoutV1 struct {
+		HardwareTimestamp uint16
+		TimestampNsec     uint64
+		Temperature       float32
+		Acceleration      [3]float32
+		AngularVelocity   [3]float32
+	}
+
+	// imuResultLayoutV1 is the binary layout of the O3R_RESULT_IMU chunk
+	imuResultLayoutV1 struct {
+		Version               uint32
+		Samples               [maxIMUSamples]imuSampleLayoutV1
+		NumSamples            uint32
+		ExtrinsicIMUToUser    Extrinsic
+		ExtrinsicIMUToVPU     Extrinsic
+		FifoReceiveTimestamps uint64
+	}
+)
+
+// IMUResultFromChunk decodes the O3R_RESULT_IMU chunk
+func IMUResultFromChunk(c *Chunk) (*IMUResult, error) {
+	if c.chunkType != O3R_RESULT_IMU {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, O3R_RESULT_IMU)
+	}
+	result := &IMUResult{}
+	if err := result.UnmarshalBinary(c.data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UnmarshalBinary decodes the data section of an O3R_RESULT_IMU chunk
+func (r *IMUResult) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("the %s data is too short: %d", O3R_RESULT_IMU, len(data))
+	}
+	version := binary.LittleEndian.Uint32(data)
+	if version < minIMUResultVersion {
+		return fmt.Errorf(
+			"unsupported %s version: %d minimum supported version: %d",
+			O3R_RESULT_IMU,
+			version,
+			minIMUResultVersion,
+		)
+	}
+	layout := imuResultLayoutV1{}
+	if err := decodeVersioned(data, &layout, version); err != nil {
+		return err
+	}
+	if layout.NumSamples > maxIMUSamples {
+		return fmt.Errorf(
+			"the number of IMU samples: %d exceeds the maximum: %d",
+			layout.NumSamples,
+			maxIMUSamples,
+		)
+	}
+	*r = IMUResult{
+		Version:            layout.Version,
+		Samples:            make([]IMUSample, layout.NumSamples),
+		ExtrinsicIMUToUser: layout.ExtrinsicIMUToUser,
+		ExtrinsicIMUToVPU:  layout.ExtrinsicIMUToVPU,
+		ReceiveTimeStamp:   time.Unix(0, int64(layout.FifoReceiveTimestamps)),
+	}
+	for i, s := range layout.Samples[:layout.NumSamples] {
+		r.Samples[i] = IMUSample{
+			HardwareTimeStamp: s.HardwareTimestamp,
+			TimeStamp:         time.Unix(0, int64(s.TimestampNsec)),
+			Temperature:       s.Temperature,
+			Acceleration:      s.Acceleration,
+			AngularVelocity:   s.AngularVelocity,
+		}
+	}
+	sort.SliceStable(r.Samples, func(i, j int) bool {
+		return r.Samples[i].TimeStamp.Before(r.Samples[j].TimeStamp)
+	})
+	return nil
+}
+
+// UserSamples returns the samples rotated from the IMU to the user coordinate system
+func (r *IMUResult) UserSamples() []IMUSample {
+	samples := make([]IMUSample, len(r.Samples))
+	for i, s := range r.Samples {
+		samples[i] = s.Rotate(r.ExtrinsicIMUToUser)
+	}
+	return samples
+}
+
+// Rotate returns the sample with the acceleration and the angular velocity
+// rotated by the Extrinsic, the translation does not apply to directions.
+func (s IMUSample) Rotate(e Extrinsic) IMUSample {
+	a := e.Rotate(Point{X: s.Acceleration[0], Y: s.Acceleration[1], Z: s.Acceleration[2]})
+	w := e.Rotate(Point{X: s.AngularVelocity[0], Y: s.AngularVelocity[1], Z: s.AngularVelocity[2]})
+	s.Acceleration = [3]float32{a.X, a.Y, a.Z}
+	s.AngularVelocity = [3]float32{w.X, w.Y, w.Z}
+	return s
+}
+
+// Orientation returns the roll and the pitch derived from the measured acceleration
+//
+// The angles are given in the coordinate system of the sample, use a sample
+// of IMUResult.UserSamples for the orientation of the user coordinate system.
+// The result is only meaningful as long as gravity dominates the acceleration.
+func (s IMUSample) Orientation() Orientation {
+	x, y, z := float64(s.Acceleration[0]), float64(s.Acceleration[1]), float64(s.Acceleration[2])
+	return Orientation{
+		Roll:  math.Atan2(y, z),
+		Pitch: math.Atan2(-x, math.Hypot(y, z)),
+	}
+}
+
+// InterpolateIMU linearly interpolates the samples, ordered by their time stamp, at the time t
+//
+// ErrNoIMUSample is returned in case t is outside of the time span of the samples.
+func InterpolateIMU(samples []IMUSample, t time.Time) (IMUSample, error) {
+	i := sort.Search(len(samples), func(i int) bool {
+		return !samples[i].TimeStamp.Before(t)
+	})
+	if i == len(samples) {
+		return IMUSample{}, ErrNoIMUSample
+	}
+	if samples[i].TimeStamp.Equal(t) {
+		return samples[i], nil
+	}
+	if i == 0 {
+		return IMUSample{}, ErrNoIMUSample
+	}
+	a, b := samples[i-1], samples[i]
+	w := float32(float64(t.Sub(a.TimeStamp)) / float64(b.TimeStamp.Sub(a.TimeStamp)))
+	sample := IMUSample{
+		HardwareTimeStamp: a.HardwareTimeStamp,
+		TimeStamp:         t,
+		Temperature:       a.Temperature + w*(b.Temperature-a.Temperature),
+	}
+	for k := 0; k < 3; k++ {
+		sample.Acceleration[k] = a.Acceleration[k] + w*(b.Acceleration[k]-a.Acceleration[k])
+		sample.AngularVelocity[k] = a.AngularVelocity[k] + w*(b.AngularVelocity[k]-a.AngularVelocity[k])
+	}
+	return sample, nil
+}
+
+// IMUStream collects the samples of consecutive O3R_RESULT_IMU chunks for time alignment
+//
+// The samples are kept in the user coordinate system, like the 3D data.
+type IMUStream struct {
+	capacity int
+	samples  []IMUSample
+}
+
+// NewIMUStream creates an IMUStream which keeps at most capacity of the latest samples
+func NewIMUStream(capacity int) *IMUStream {
+	return &IMUStream{
+		capacity: capacity,
+		samples:  make([]IMUSample, 0, capacity),
+	}
+}
+
+// Add appends the samples of the result rotated to the user coordinate system,
+// samples already known are skipped
+func (s *IMUStream) Add(result *IMUResult) {
+	for _, sample := range result.UserSamples() {
+		i := sort.Search(len(s.samples), func(i int) bool {
+			return !s.samples[i].TimeStamp.Before(sample.TimeStamp)
+		})
+		if i < len(s.samples) && s.samples[i].TimeStamp.Equal(sample.TimeStamp) {
+			continue
+		}
+		s.samples = append(s.samples, IMUSample{})
+		copy(s.samples[i+1:], s.samples[i:])
+		s.samples[i] = sample
+	}
+	if overflow := len(s.samples) - s.capacity; overflow > 0 {
+		s.samples = append(s.samples[:0], s.samples[overflow:]...)
+	}
+}
+
+// Samples returns the collected samples ordered by their time stamp
+func (s *IMUStream) Samples() []IMUSample {
+	return s.samples
+}
+
+// Interpolate returns the sample interpolated at the time t
+func (s *IMUStream) Interpolate(t time.Time) (IMUSample, error) {
+	return InterpolateIMU(s.samples, t)
+}
+
+// OrientationAt returns the orientation interpolated at the time t, e.g. the TimeStamp of a 3D Chunk
+func (s *IMUStream) OrientationAt(t time.Time) (Orientation, error) {
+	sample, err := s.Interpolate(t)
+	if err != nil {
+		return Orientation{}, err
+	}
+	return sample.Orientation(), nil
+}
@@ -0,0 +1,147 @@
+package pcic_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// imuSampleV1 mirrors the binary layout of a single IMU sample
+type imuSampleV1 struct {
+	HardwareTimestamp uint16
+	TimestampNsec     uint64
+	Temperature       float32
+	Acceleration      [3]float32
+	AngularVelocity   [3]float32
+}
+
+// imuResultV1 mirrors the binary layout of the O3R_RESULT_IMU chunk
+type imuResultV1 struct {
+	Version               uint32
+	Samples               [128]imuSampleV1
+	NumSamples            uint32
+	ExtrinsicIMUToUser    [6]float32
+	ExtrinsicIMUToVPU     [6]float32
+	FifoReceiveTimestamps uint64
+}
+
+func imuChunk(t *testing.T, samples ...imuSampleV1) *pcic.Chunk {
+	return rotatedIMUChunk(t, [6]float32{0.1, 0, 0, 0, 0, 0}, samples...)
+}
+
+// rotatedIMUChunk creates an O3R_RESULT_IMU chunk with the given IMU to user extrinsic
+func rotatedIMUChunk(t *testing.T, extrinsic [6]float32, samples ...imuSampleV1) *pcic.Chunk {
+	result := imuResultV1{
+		Version:               1,
+		NumSamples:            uint32(len(samples)),
+		ExtrinsicIMUToUser:    extrinsic,
+		FifoReceiveTimestamps: 1700000001000000000,
+	}
+	copy(result.Samples[:], samples)
+	buffer := bytes.Buffer{}
+	assert.NoError(t, binary.Write(&buffer, binary.LittleEndian, result), "We expect no error while encoding")
+	return bytesChunk(pcic.O3R_RESULT_IMU, buffer.Bytes())
+}
+
+func imuSample(nsec uint64, acceleration [3]float32) imuSampleV1 {
+	return imuSampleV1{
+		TimestampNsec:   1700000000000000000 + nsec,
+		Temperature:     30,
+		Acceleration:    acceleration,
+		AngularVelocity: [3]float32{0.1, 0.2, 0.3},
+	}
+}
+
+func TestIMUResultFromChunk(t *testing.T) {
+	result, err := pcic.IMUResultFromChunk(imuChunk(t,
+		imuSample(2000, [3]float32{0, 0, 9.81}),
+		imuSample(1000, [3]float32{0, 9.81, 0}),
+	))
+	assert.NoError(t, err, "We expect no error while decoding the IMU result")
+	assert.Equal(t, uint32(1), result.Version, "A version mismatch occurred")
+	assert.Equal(t, 2, len(result.Samples), "A sample count mismatch occurred")
+	assert.Equal(t, time.Unix(1700000000, 1000), result.Samples[0].TimeStamp, "We expect the samples to be ordered")
+	assert.Equal(t, [3]float32{0, 9.81, 0}, result.Samples[0].Acceleration, "An acceleration mismatch occurred")
+	assert.Equal(t, [3]float32{0.1, 0.2, 0.3}, result.Samples[1].AngularVelocity)
+	assert.Equal(t, float32(0.1), result.ExtrinsicIMUToUser.TransX, "An extrinsic mismatch occurred")
+	assert.Equal(t, time.Unix(1700000001, 0), result.ReceiveTimeStamp, "A time stamp mismatch occurred")
+
+	_, err = pcic.IMUResultFromChunk(bytesChunk(pcic.O3R_RESULT_IMU, make([]byte, 100)))
+	assert.Error(t, err, "We expect an error due to truncated data")
+	_, err = pcic.IMUResultFromChunk(bytesChunk(pcic.TOF_INFO, make([]byte, 100)))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+	data := imuChunk(t).Bytes()
+	binary.LittleEndian.PutUint32(data, 0)
+	_, err = pcic.IMUResultFromChunk(bytesChunk(pcic.O3R_RESULT_IMU, data))
+	assert.Error(t, err, "We expect an error due to an unsupported version")
+}
+
+func TestIMUUserSamples(t *testing.T) {
+	// The IMU is mounted rotated by 90 degree around the X axis of the user coordinate system
+	result, err := pcic.IMUResultFromChunk(rotatedIMUChunk(t,
+		[6]float32{0.1, 0.2, 0.3, math.Pi / 2, 0, 0},
+		imuSample(1000, [3]float32{0, 0, 9.81}),
+		imuSample(2000, [3]float32{0, 0, 9.81}),
+	))
+	assert.NoError(t, err, "We expect no error while decoding the IMU result")
+	assert.Equal(t, [3]float32{0, 0, 9.81}, result.Samples[0].Acceleration, "We expect the samples in the IMU frame")
+	user := result.UserSamples()[0]
+	assert.InDeltaSlice(t, []float32{0, -9.81, 0}, user.Acceleration[:], 1e-5, "An acceleration mismatch occurred")
+	assert.InDeltaSlice(t, []float32{0.1, -0.3, 0.2}, user.AngularVelocity[:], 1e-6, "An angular velocity mismatch occurred")
+	assert.InDelta(t, 0, result.Samples[0].Orientation().Roll, 1e-9, "We expect the IMU to be level")
+	assert.InDelta(t, -math.Pi/2, user.Orientation().Roll, 1e-6, "We expect the roll of the user frame")
+
+	stream := pcic.NewIMUStream(10)
+	stream.Add(result)
+	orientation, err := stream.OrientationAt(time.Unix(1700000000, 1500))
+	assert.NoError(t, err, "We expect no error while interpolating")
+	assert.InDelta(t, -math.Pi/2, orientation.Roll, 1e-6, "We expect the stream in the user frame")
+}
+
+func TestIMUOrientation(t *testing.T) {
+	level := pcic.IMUSample{Acceleration: [3]float32{0, 0, 9.81}}
+	assert.InDelta(t, 0, level.Orientation().Roll, 1e-9, "A roll mismatch occurred")
+	assert.InDelta(t, 0, level.Orientation().Pitch, 1e-9, "A pitch mismatch occurred")
+	rolled := pcic.IMUSample{Acceleration: [3]float32{0, 9.81, 0}}
+	assert.InDelta(t, math.Pi/2, rolled.Orientation().Roll, 1e-6, "A roll mismatch occurred")
+	pitched := pcic.IMUSample{Acceleration: [3]float32{-1, 0, 1}}
+	assert.InDelta(t, math.Pi/4, pitched.Orientation().Pitch, 1e-6, "A pitch mismatch occurred")
+}
+
+func TestIMUStream(t *testing.T) {
+	stream := pcic.NewIMUStream(3)
+	first, err := pcic.IMUResultFromChunk(imuChunk(t,
+		imuSample(1000, [3]float32{0, 0, 10}),
+		imuSample(2000, [3]float32{0, 10, 10}),
+	))
+	assert.NoError(t, err, "We expect no error while decoding the IMU result")
+	stream.Add(first)
+	second, err := pcic.IMUResultFromChunk(imuChunk(t,
+		imuSample(2000, [3]float32{0, 10, 10}),
+		imuSample(3000, [3]float32{0, 10, 0}),
+		imuSample(4000, [3]float32{0, 10, 0}),
+	))
+	assert.NoError(t, err, "We expect no error while decoding the IMU result")
+	stream.Add(second)
+	assert.Equal(t, 3, len(stream.Samples()), "We expect duplicates to be skipped and the capacity to be kept")
+	assert.Equal(t, time.Unix(1700000000, 2000), stream.Samples()[0].TimeStamp, "We expect the oldest sample to be dropped")
+
+	sample, err := stream.Interpolate(time.Unix(1700000000, 2500))
+	assert.NoError(t, err, "We expect no error while interpolating")
+	assert.Equal(t, [3]float32{0, 10, 5}, sample.Acceleration, "An acceleration mismatch occurred")
+	assert.Equal(t, time.Unix(1700000000, 2500), sample.TimeStamp, "A time stamp mismatch occurred")
+
+	orientation, err := stream.OrientationAt(time.Unix(1700000000, 3000))
+	assert.NoError(t, err, "We expect no error while interpolating")
+	assert.InDelta(t, math.Pi/2, orientation.Roll, 1e-6, "A roll mismatch occurred")
+
+	_, err = stream.OrientationAt(time.Unix(1700000000, 1500))
+	assert.ErrorIs(t, err, pcic.ErrNoIMUSample, "We expect an error before the first sample")
+	_, err = stream.OrientationAt(time.Unix(1700000000, 4001))
+	assert.ErrorIs(t, err, pcic.ErrNoIMUSample, "We expect an error after the last sample")
+}
@@ -0,0 +1,97 @@
+package pcic
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// JSONError is returned in case the data of a JSON chunk can not be decoded
+type JSONError struct {
+	ChunkType ChunkType // The type of the Chunk holding the malformed JSON
+	Offset    int64     // The byte offset of the syntax error, -1 if unknown
+	Err       error     // The error reported by the JSON decoder
+}
+
+func (e *JSONError) Error() string {
+	if e.Offset < 0 {
+		return fmt.Sprintf("malformed JSON in the chunk %s: %v", e.ChunkType, e.Err)
+	}
+	return fmt.Sprintf("malformed JSON in the chunk %s at offset %d: %v", e.ChunkType, e.Offset, e.Err)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
+
+// IsJSON reports whether the Chunk holds a single line of valid JSON
+//
+// Trailing zero bytes, used by some applications for padding, are ignored.
+func (c *Chunk) IsJSON() bool {
+	return c.dataFormat == FORMAT_8U && c.dataHeight <= 1 && json.Valid(c.jsonData())
+}
+
+// DecodeJSON decodes the JSON data of the Chunk into v
+//
+// A *JSONError is returned in case the data is malformed or does not match v.
+func (c *Chunk) DecodeJSON(v any) error {
+	if c.dataFormat != FORMAT_8U || c.dataHeight > 1 {
+		return fmt.Errorf(
+			"the chunk %s with the format %s and the height %d can not hold JSON",
+			c.chunkType,
+			c.dataFormat,
+			c.dataHeight,
+		)
+	}
+	if err := json.Unmarshal(c.jsonData(), v); err != nil {
+		jsonErr := &JSONError{ChunkType: c.chunkType, Offset: -1, Err: err}
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			jsonErr.Offset = syntaxErr.Offset
+		case errors.As(err, &typeErr):
+			jsonErr.Offset = typeErr.Offset
+		}
+		return jsonErr
+	}
+	return nil
+}
+
+// JSON decodes the JSON object of the Chunk into a map
+func (c *Chunk) JSON() (map[string]any, error) {
+	return JSONFromChunk[map[string]any](c)
+}
+
+// JSONFromChunk decodes the JSON data of the Chunk into a new value of the type T
+func JSONFromChunk[T any](c *Chunk) (T, error) {
+	var v T
+	err := c.DecodeJSON(&v)
+	return v, err
+}
+
+// jsonData returns the data without the trailing zero padding
+func (c *Chunk) jsonData() []byte {
+	return bytes.TrimRight(c.data, "\x00")
+}
+
+// JSONChunks returns the chunks of the Frame which hold JSON
+func (f *Frame) JSONChunks() []*Chunk {
+	chunks := []*Chunk{}
+	for i := range f.Chunks {
+		if f.Chunks[i].IsJSON() {
+			chunks = append(chunks, &f.Chunks[i])
+		}
+	}
+	return chunks
+}
+
+// DecodeJSON decodes the JSON data of the first Chunk of the given type into v
+func (f *Frame) DecodeJSON(chunkType ChunkType, v any) error {
+	chunk, ok := f.ChunkByType(chunkType)
+	if !ok {
+		return fmt.Errorf("the frame does not contain the %s chunk", chunkType)
+	}
+	return chunk.DecodeJSON(v)
+}
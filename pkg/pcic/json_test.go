@@ -0,0 +1,69 @@
+package pcic_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunkIsJSON(t *testing.T) {
+	assert.True(t, bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"a": 1}`)).IsJSON())
+	assert.True(t, bytesChunk(pcic.ChunkType(2000), []byte("[1, 2]\x00\x00")).IsJSON(),
+		"We expect trailing zero padding to be ignored",
+	)
+	assert.False(t, bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"a": `)).IsJSON())
+	assert.False(t, uint16Chunk(pcic.O3R_RESULT_JSON, 1, 1, []uint16{0x3030}).IsJSON(),
+		"We expect a chunk with a format other than FORMAT_8U not to hold JSON",
+	)
+}
+
+func TestChunkDecodeJSON(t *testing.T) {
+	chunk := bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"name": "ods", "zones": [1, 2]}`))
+	doc, err := chunk.JSON()
+	assert.NoError(t, err, "We expect no error while decoding the JSON chunk")
+	assert.Equal(t, "ods", doc["name"], "A value mismatch occurred")
+
+	type result struct {
+		Name  string `json:"name"`
+		Zones []int  `json:"zones"`
+	}
+	typed, err := pcic.JSONFromChunk[result](chunk)
+	assert.NoError(t, err, "We expect no error while decoding the JSON chunk")
+	assert.Equal(t, result{Name: "ods", Zones: []int{1, 2}}, typed, "A value mismatch occurred")
+
+	_, err = pcic.JSONFromChunk[result](uint16Chunk(pcic.O3R_RESULT_JSON, 1, 1, []uint16{0}))
+	assert.Error(t, err, "We expect an error due to a data format mismatch")
+}
+
+func TestChunkMalformedJSON(t *testing.T) {
+	_, err := bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"name": ]`)).JSON()
+	var jsonErr *pcic.JSONError
+	assert.ErrorAs(t, err, &jsonErr, "We expect a JSONError")
+	assert.Equal(t, pcic.O3R_RESULT_JSON, jsonErr.ChunkType, "A chunk type mismatch occurred")
+	assert.Equal(t, int64(10), jsonErr.Offset, "An offset mismatch occurred")
+	var syntaxErr *json.SyntaxError
+	assert.ErrorAs(t, err, &syntaxErr, "We expect the JSONError to wrap the syntax error")
+
+	_, err = pcic.JSONFromChunk[[]int](bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"name": 1}`)))
+	assert.ErrorAs(t, err, &jsonErr, "We expect a JSONError due to a type mismatch")
+
+	_, err = pcic.PDSResultFromChunk(bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{`)))
+	assert.ErrorAs(t, err, &jsonErr, "We expect the PDS decoder to report a JSONError")
+}
+
+func TestFrameJSONChunks(t *testing.T) {
+	frame := pcic.Frame{Chunks: []pcic.Chunk{
+		*uint16Chunk(pcic.RADIAL_DISTANCE_IMAGE, 1, 1, []uint16{1}),
+		*bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"id": 7}`)),
+	}}
+	chunks := frame.JSONChunks()
+	assert.Equal(t, 1, len(chunks), "A JSON chunk count mismatch occurred")
+	assert.Equal(t, pcic.O3R_RESULT_JSON, chunks[0].Type(), "A chunk type mismatch occurred")
+
+	var doc struct{ ID int }
+	assert.NoError(t, frame.DecodeJSON(pcic.O3R_RESULT_JSON, &doc), "We expect no error while decoding")
+	assert.Equal(t, 7, doc.ID, "A value mismatch occurred")
+	assert.Error(t, frame.DecodeJSON(pcic.O3R_ODS_INFO, &doc), "We expect an error due to a missing chunk")
+}
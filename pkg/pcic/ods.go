@@ -0,0 +1,161 @@
+package pcic
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"time"
+)
+
+// The cell value from which on a cell of the OccupancyGrid is considered as occupied
+const OccupiedThreshold uint8 = 127
+
+type (
+	// OccupancyGrid is the decoded O3R_ODS_OCCUPANCY_GRID chunk
+	OccupancyGrid struct {
+		TimeStamp time.Time  // The time the grid was created
+		Width     int        // The number of cells in a row
+		Height    int        // The number of rows
+		Transform [6]float32 // The affine 2x3 matrix from the cell center to the user coordinate system
+		Cells     []uint8    // The occupancy probability of each cell in row major order
+	}
+
+	// ZoneResult is the decoded O3R_ODS_INFO chunk
+	ZoneResult struct {
+		TimeStamp    time.Time // The time the zones were evaluated
+		ZoneConfigID int32     // The ID of the active zone configuration
+		Occupied     [3]bool   // The occupied state of each zone
+	}
+
+	// occupancyGridLayoutV1 is the binary layout of the O3R_ODS_OCCUPANCY_GRID header
+	occupancyGridLayoutV1 struct {
+		TimestampNsec uint64
+		Width         uint32
+		Height        uint32
+		Transform     [6]float32
+	}
+
+	// zoneResultLayoutV1 is the binary layout of the O3R_ODS_INFO chunk
+	zoneResultLayoutV1 struct {
+		TimestampNsec uint64
+		ZoneOccupied  [3]uint8
+		ZoneConfigID  int32
+	}
+)
+
+// OccupancyGridFromChunk decodes the O3R_ODS_OCCUPANCY_GRID chunk
+func OccupancyGridFromChunk(c *Chunk) (*OccupancyGrid, error) {
+	if c.chunkType != O3R_ODS_OCCUPANCY_GRID {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, O3R_ODS_OCCUPANCY_GRID)
+	}
+	grid := &OccupancyGrid{}
+	if err := grid.UnmarshalBinary(c.data); err != nil {
+		return nil, err
+	}
+	return grid, nil
+}
+
+// UnmarshalBinary decodes the data section of an O3R_ODS_OCCUPANCY_GRID chunk
+func (g *OccupancyGrid) UnmarshalBinary(data []byte) error {
+	header := occupancyGridLayoutV1{}
+	if err := decodeVersioned(data, &header, 1); err != nil {
+		return err
+	}
+	cells := data[binary.Size(header):]
+	if uint64(len(cells)) != uint64(header.Width)*uint64(header.Height) {
+		return fmt.Errorf(
+			"the %d cells do not match the grid dimension %dx%d",
+			len(cells),
+			header.Width,
+			header.Height,
+		)
+	}
+	*g = OccupancyGrid{
+		TimeStamp: time.Unix(0, int64(header.TimestampNsec)),
+		Width:     int(header.Width),
+		Height:    int(header.Height),
+		Transform: header.Transform,
+		Cells:     append([]uint8(nil), cells...),
+	}
+	return nil
+}
+
+// At returns the value of the cell at the column x and the row y, it panics if the position is out of range
+func (g *OccupancyGrid) At(x, y int) uint8 {
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		panic(fmt.Sprintf("pcic: cell (%d,%d) out of range %dx%d", x, y, g.Width, g.Height))
+	}
+	return g.Cells[y*g.Width+x]
+}
+
+// Occupied reports whether the cell at the column x and the row y reaches the OccupiedThreshold
+func (g *OccupancyGrid) Occupied(x, y int) bool {
+	return g.At(x, y) >= OccupiedThreshold
+}
+
+// CellToWorld returns the center of the cell in the user coordinate system, in meters
+func (g *OccupancyGrid) CellToWorld(x, y int) (float64, float64) {
+	t := g.Transform
+	fx, fy := float64(x), float64(y)
+	return float64(t[0])*fx + float64(t[1])*fy + float64(t[2]),
+		float64(t[3])*fx + float64(t[4])*fy + float64(t[5])
+}
+
+// WorldToCell returns the cell containing the position of the user coordinate system
+//
+// The result is false in case the position is outside of the grid or the
+// transformation is not invertible.
+func (g *OccupancyGrid) WorldToCell(wx, wy float64) (int, int, bool) {
+	t := g.Transform
+	a, b, c := float64(t[0]), float64(t[1]), float64(t[2])
+	d, e, f := float64(t[3]), float64(t[4]), float64(t[5])
+	det := a*e - b*d
+	if det == 0 {
+		return 0, 0, false
+	}
+	dx, dy := wx-c, wy-f
+	x := int(math.Round((e*dx - b*dy) / det))
+	y := int(math.Round((a*dy - d*dx) / det))
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// ZoneResultFromChunk decodes the O3R_ODS_INFO chunk
+func ZoneResultFromChunk(c *Chunk) (*ZoneResult, error) {
+	if c.chunkType != O3R_ODS_INFO {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, O3R_ODS_INFO)
+	}
+	result := &ZoneResult{}
+	if err := result.UnmarshalBinary(c.data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UnmarshalBinary decodes the data section of an O3R_ODS_INFO chunk
+func (r *ZoneResult) UnmarshalBinary(data []byte) error {
+	layout := zoneResultLayoutV1{}
+	if err := decodeVersioned(data, &layout, 1); err != nil {
+		return err
+	}
+	*r = ZoneResult{
+		TimeStamp:    time.Unix(0, int64(layout.TimestampNsec)),
+		ZoneConfigID: layout.ZoneConfigID,
+	}
+	for i, occupied := range layout.ZoneOccupied {
+		r.Occupied[i] = occupied != 0
+	}
+	return nil
+}
+
+// AnyOccupied reports whether at least one zone is occupied
+func (r *ZoneResult) AnyOccupied() bool {
+	for _, occupied := range r.Occupied {
+		if occupied {
+			return true
+		}
+	}
+	return false
+}
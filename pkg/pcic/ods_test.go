@@ -0,0 +1,74 @@
+package pcic_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func occupancyGridChunk(t *testing.T, width, height uint32, transform [6]float32, cells []uint8) *pcic.Chunk {
+	buffer := bytes.Buffer{}
+	for _, v := range []any{uint64(1700000000000000000), width, height, transform, cells} {
+		assert.NoError(t, binary.Write(&buffer, binary.LittleEndian, v), "We expect no error while encoding")
+	}
+	return bytesChunk(pcic.O3R_ODS_OCCUPANCY_GRID, buffer.Bytes())
+}
+
+func TestOccupancyGridFromChunk(t *testing.T) {
+	// 5cm cells with the cell (0, 0) at (-0.1, 0.05)
+	transform := [6]float32{0.05, 0, -0.1, 0, 0.05, 0.05}
+	grid, err := pcic.OccupancyGridFromChunk(occupancyGridChunk(t, 3, 2, transform, []uint8{
+		0, 0, 255,
+		126, 127, 0,
+	}))
+	assert.NoError(t, err, "We expect no error while decoding the occupancy grid")
+	assert.Equal(t, time.Unix(1700000000, 0), grid.TimeStamp, "A time stamp mismatch occurred")
+	assert.Equal(t, 3, grid.Width, "A width mismatch occurred")
+	assert.Equal(t, 2, grid.Height, "A height mismatch occurred")
+	assert.Equal(t, uint8(255), grid.At(2, 0), "A cell mismatch occurred")
+	assert.True(t, grid.Occupied(2, 0), "We expect the cell to be occupied")
+	assert.False(t, grid.Occupied(0, 1), "We expect the cell to be free")
+	assert.True(t, grid.Occupied(1, 1), "We expect the cell to be occupied")
+	assert.Panics(t, func() { grid.At(3, 0) }, "We expect a panic when out of range")
+
+	x, y := grid.CellToWorld(2, 1)
+	assert.InDelta(t, 0, x, 1e-6, "A X position mismatch occurred")
+	assert.InDelta(t, 0.1, y, 1e-6, "A Y position mismatch occurred")
+	cx, cy, ok := grid.WorldToCell(0.01, 0.09)
+	assert.True(t, ok, "We expect the position to be inside the grid")
+	assert.Equal(t, 2, cx, "A column mismatch occurred")
+	assert.Equal(t, 1, cy, "A row mismatch occurred")
+	_, _, ok = grid.WorldToCell(1, 1)
+	assert.False(t, ok, "We expect the position to be outside of the grid")
+}
+
+func TestOccupancyGridMalformed(t *testing.T) {
+	_, err := pcic.OccupancyGridFromChunk(occupancyGridChunk(t, 3, 2, [6]float32{}, []uint8{0, 0}))
+	assert.Error(t, err, "We expect an error due to a cell count mismatch")
+	_, err = pcic.OccupancyGridFromChunk(bytesChunk(pcic.O3R_ODS_OCCUPANCY_GRID, []byte{1, 2, 3}))
+	assert.Error(t, err, "We expect an error due to truncated data")
+	_, err = pcic.OccupancyGridFromChunk(bytesChunk(pcic.O3R_ODS_INFO, make([]byte, 64)))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+}
+
+func TestZoneResultFromChunk(t *testing.T) {
+	buffer := bytes.Buffer{}
+	for _, v := range []any{uint64(1700000000000000123), [3]uint8{0, 1, 0}, int32(4)} {
+		assert.NoError(t, binary.Write(&buffer, binary.LittleEndian, v), "We expect no error while encoding")
+	}
+	result, err := pcic.ZoneResultFromChunk(bytesChunk(pcic.O3R_ODS_INFO, buffer.Bytes()))
+	assert.NoError(t, err, "We expect no error while decoding the zone result")
+	assert.Equal(t, time.Unix(1700000000, 123), result.TimeStamp, "A time stamp mismatch occurred")
+	assert.Equal(t, int32(4), result.ZoneConfigID, "A zone config mismatch occurred")
+	assert.Equal(t, [3]bool{false, true, false}, result.Occupied, "A zone state mismatch occurred")
+	assert.True(t, result.AnyOccupied(), "We expect a zone to be occupied")
+
+	_, err = pcic.ZoneResultFromChunk(bytesChunk(pcic.O3R_ODS_INFO, buffer.Bytes()[:10]))
+	assert.Error(t, err, "We expect an error due to truncated data")
+	_, err = pcic.ZoneResultFromChunk(bytesChunk(pcic.O3R_ODS_OCCUPANCY_GRID, buffer.Bytes()))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+}
@@ -0,0 +1,105 @@
+package pcic
+
+import "fmt"
+
+type (
+	// Vector is a position or a direction in the user coordinate system, in meters
+	Vector struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+		Z float64 `json:"z"`
+	}
+
+	// Angles is the rotation around the axes of the user coordinate system, in radians
+	Angles struct {
+		RotX float64 `json:"rotX"`
+		RotY float64 `json:"rotY"`
+		RotZ float64 `json:"rotZ"`
+	}
+
+	// Pose is the position and the orientation of a detected object
+	Pose struct {
+		Center Vector `json:"center"`
+		Angles Angles `json:"angles"`
+	}
+
+	// Pocket is a fork pocket of a pallet
+	Pocket struct {
+		Center Vector  `json:"center"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+	}
+
+	// Pallet is a single pallet detected by the getPallet command
+	Pallet struct {
+		Pose
+		Score       float64 `json:"score"`
+		LeftPocket  Pocket  `json:"leftPocket"`
+		RightPocket Pocket  `json:"rightPocket"`
+	}
+
+	// PalletResult is the result of the getPallet command
+	PalletResult struct {
+		NumDetectedPallets int      `json:"numDetectedPallets"`
+		Pallets            []Pallet `json:"pallet"`
+	}
+
+	// Beam is the horizontal beam of a rack
+	Beam struct {
+		Pose
+		Score float64 `json:"score"`
+	}
+
+	// RackResult is the result of the getRack command
+	RackResult struct {
+		NumDetectedBeams int     `json:"numDetectedBeams"`
+		Beams            []Beam  `json:"beam"`
+		LeftUpright      *Vector `json:"leftUpright,omitempty"`
+		RightUpright     *Vector `json:"rightUpright,omitempty"`
+	}
+
+	// VolumeCheckResult is the result of the volCheck command
+	VolumeCheckResult struct {
+		NumPixels    int     `json:"numPixels"`              // The number of pixels inside the volume
+		NearestPoint *Vector `json:"nearestPoint,omitempty"` // The point inside the volume closest to the camera
+	}
+
+	// Item is a single item detected by the getItem command
+	Item struct {
+		Pose
+		Score float64 `json:"score"`
+	}
+
+	// ItemResult is the result of the getItem command
+	ItemResult struct {
+		NumDetectedItems int    `json:"numDetectedItems"`
+		Items            []Item `json:"item"`
+	}
+
+	// PDSResult is the result of a command of the Pallet Detection System (PDS) application
+	//
+	// Only the result of the triggered command is set.
+	PDSResult struct {
+		GetPallet *PalletResult      `json:"getPallet,omitempty"`
+		GetRack   *RackResult        `json:"getRack,omitempty"`
+		VolCheck  *VolumeCheckResult `json:"volCheck,omitempty"`
+		GetItem   *ItemResult        `json:"getItem,omitempty"`
+	}
+)
+
+// Empty reports whether the VolumeCheckResult found no obstacle
+func (r *VolumeCheckResult) Empty() bool {
+	return r.NumPixels == 0
+}
+
+// PDSResultFromChunk decodes the O3R_RESULT_JSON chunk of a PDS application
+func PDSResultFromChunk(c *Chunk) (*PDSResult, error) {
+	if c.chunkType != O3R_RESULT_JSON {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, O3R_RESULT_JSON)
+	}
+	result := &PDSResult{}
+	if err := c.DecodeJSON(result); err != nil {
+		return nil, fmt.Errorf("unable to decode the PDS result: %w", err)
+	}
+	return result, nil
+}
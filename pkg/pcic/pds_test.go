@@ -0,0 +1,71 @@
+package pcic_test
+
+import (
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPDSPalletResult(t *testing.T) {
+	result, err := pcic.PDSResultFromChunk(bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{
+		"getPallet": {
+			"numDetectedPallets": 1,
+			"pallet": [{
+				"score": 0.93,
+				"center": {"x": 1.5, "y": 0.02, "z": -0.1},
+				"angles": {"rotX": 0, "rotY": 0, "rotZ": 0.05},
+				"leftPocket": {"center": {"x": 1.5, "y": 0.3, "z": -0.1}, "width": 0.25, "height": 0.1},
+				"rightPocket": {"center": {"x": 1.5, "y": -0.3, "z": -0.1}, "width": 0.25, "height": 0.1}
+			}]
+		}
+	}`)))
+	assert.NoError(t, err, "We expect no error while decoding the PDS result")
+	assert.Nil(t, result.GetRack, "We expect only the getPallet result")
+	assert.Equal(t, 1, result.GetPallet.NumDetectedPallets, "A pallet count mismatch occurred")
+	pallet := result.GetPallet.Pallets[0]
+	assert.Equal(t, 0.93, pallet.Score, "A score mismatch occurred")
+	assert.Equal(t, pcic.Vector{X: 1.5, Y: 0.02, Z: -0.1}, pallet.Center, "A center mismatch occurred")
+	assert.Equal(t, 0.05, pallet.Angles.RotZ, "An angle mismatch occurred")
+	assert.Equal(t, 0.3, pallet.LeftPocket.Center.Y, "A pocket mismatch occurred")
+	assert.Equal(t, 0.25, pallet.RightPocket.Width, "A pocket mismatch occurred")
+}
+
+func TestPDSRackVolumeAndItemResults(t *testing.T) {
+	result, err := pcic.PDSResultFromChunk(bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{
+		"getRack": {
+			"numDetectedBeams": 1,
+			"beam": [{"score": 0.8, "center": {"x": 2, "y": 0, "z": 0.5}, "angles": {"rotX": 0, "rotY": 0, "rotZ": 0}}],
+			"leftUpright": {"x": 2, "y": 1, "z": 0}
+		}
+	}`)))
+	assert.NoError(t, err, "We expect no error while decoding the PDS result")
+	assert.Equal(t, 0.5, result.GetRack.Beams[0].Center.Z, "A beam mismatch occurred")
+	assert.Equal(t, &pcic.Vector{X: 2, Y: 1}, result.GetRack.LeftUpright, "An upright mismatch occurred")
+	assert.Nil(t, result.GetRack.RightUpright, "We expect no right upright")
+
+	result, err = pcic.PDSResultFromChunk(bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{"volCheck": {"numPixels": 0}}`)))
+	assert.NoError(t, err, "We expect no error while decoding the PDS result")
+	assert.True(t, result.VolCheck.Empty(), "We expect the volume to be empty")
+
+	result, err = pcic.PDSResultFromChunk(bytesChunk(pcic.O3R_RESULT_JSON, []byte(`{
+		"getItem": {"numDetectedItems": 1, "item": [{"score": 0.7, "center": {"x": 1, "y": 0, "z": 0}}]}
+	}`)))
+	assert.NoError(t, err, "We expect no error while decoding the PDS result")
+	assert.Equal(t, 0.7, result.GetItem.Items[0].Score, "A score mismatch occurred")
+}
+
+func TestPDSMalformedResult(t *testing.T) {
+	for _, data := range []string{
+		`{"getPallet": [}`,
+		`{"getPallet": {"numDetectedPallets": "one"}}`,
+		`{"getRack": {"beam": {"score": 1}}}`,
+		`{"volCheck": {"numPixels": -1.5}}`,
+		`{"getItem": {"item": [{"center": [1, 2, 3]}]}}`,
+	} {
+		_, err := pcic.PDSResultFromChunk(bytesChunk(pcic.O3R_RESULT_JSON, []byte(data)))
+		assert.Error(t, err, "We expect an error while decoding: %s", data)
+	}
+	_, err := pcic.PDSResultFromChunk(bytesChunk(pcic.TOF_INFO, []byte(`{}`)))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+}
@@ -0,0 +1,186 @@
+package pcic
+
+import "fmt"
+
+type (
+	// PointCloud holds a point for each pixel of a 3D frame in the user coordinate system
+	PointCloud struct {
+		width  int
+		height int
+		points []Point
+		valid  []bool
+	}
+
+	// PointCloudOption configures the computation of a PointCloud
+	PointCloudOption func(*pointCloudConfig)
+
+	pointCloudConfig struct {
+		confidence *Chunk
+		rejected   ConfidenceFlags
+	}
+)
+
+// WithConfidence masks the pixels marked as invalid in the CONFIDENCE_IMAGE chunk
+func WithConfidence(confidence *Chunk) PointCloudOption {
+	return func(c *pointCloudConfig) {
+		c.confidence = confidence
+	}
+}
+
+// WithRejectedFlags masks the pixels with any of the given confidence flags set
+//
+// The default is CONFIDENCE_INVALID, the option only has an effect in
+// combination with a confidence image.
+func WithRejectedFlags(flags ConfidenceFlags) PointCloudOption {
+	return func(c *pointCloudConfig) {
+		c.rejected = flags
+	}
+}
+
+// PointCloudFromFrame computes the PointCloud from the chunks of the Frame
+//
+// The Frame has to contain the RADIAL_DISTANCE_IMAGE, UNIT_VECTOR_ALL and
+// TOF_INFO chunks. A CONFIDENCE_IMAGE chunk is used for masking when present.
+func PointCloudFromFrame(f *Frame, options ...PointCloudOption) (*PointCloud, error) {
+	chunks := [3]*Chunk{}
+	for i, chunkType := range []ChunkType{RADIAL_DISTANCE_IMAGE, UNIT_VECTOR_ALL, TOF_INFO} {
+		chunk, ok := f.ChunkByType(chunkType)
+		if !ok {
+			return nil, fmt.Errorf("the frame does not contain the %s chunk", chunkType)
+		}
+		chunks[i] = chunk
+	}
+	info, err := TOFInfoFromChunk(chunks[2])
+	if err != nil {
+		return nil, err
+	}
+	if confidence, ok := f.ChunkByType(CONFIDENCE_IMAGE); ok {
+		options = append([]PointCloudOption{WithConfidence(confidence)}, options...)
+	}
+	return PointCloudFromChunks(chunks[0], chunks[1], info, options...)
+}
+
+// PointCloudFromChunks computes the PointCloud from the radial distance and the unit vectors
+//
+// Each point is the unit vector scaled by the radial distance and transformed
+// by the extrinsic calibration of the TOFInfo. The distance is either given
+// in meters as FORMAT_32F or in digits of the DistanceResolution as
+// FORMAT_16U. Pixels without a distance or marked as invalid by the
+// confidence image are set to the zero Point and reported as not valid.
+func PointCloudFromChunks(distance, unitVectors *Chunk, info *TOFInfo, options ...PointCloudOption) (*PointCloud, error) {
+	config := pointCloudConfig{rejected: CONFIDENCE_INVALID}
+	for _, opt := range options {
+		opt(&config)
+	}
+	meters, err := distanceInMeters(distance, info.DistanceResolution)
+	if err != nil {
+		return nil, err
+	}
+	vectors, err := ImageFromChunk[float32](unitVectors)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors.pix) != 3*len(meters.pix) {
+		return nil, fmt.Errorf(
+			"the %d unit vector components do not match the %d distance pixels",
+			len(vectors.pix),
+			len(meters.pix),
+		)
+	}
+	var mask []bool
+	if config.confidence != nil {
+		confidence, err := ConfidenceFromChunk(config.confidence)
+		if err != nil {
+			return nil, err
+		}
+		if confidence.Width() != meters.width || confidence.Height() != meters.height {
+			return nil, fmt.Errorf(
+				"the confidence dimension %dx%d does not match the distance dimension %dx%d",
+				confidence.Width(),
+				confidence.Height(),
+				meters.width,
+				meters.height,
+			)
+		}
+		mask = confidence.Mask(config.rejected)
+	}
+
+	cloud := &PointCloud{
+		width:  meters.width,
+		height: meters.height,
+		points: make([]Point, len(meters.pix)),
+		valid:  make([]bool, len(meters.pix)),
+	}
+	transform := info.ExtrinsicOpticToUser.transformation()
+	for i, d := range meters.pix {
+		if d <= 0 || (mask != nil && !mask[i]) {
+			continue
+		}
+		r := float64(d)
+		cloud.points[i] = transform.apply(
+			float64(vectors.pix[3*i])*r,
+			float64(vectors.pix[3*i+1])*r,
+			float64(vectors.pix[3*i+2])*r,
+		)
+		cloud.valid[i] = true
+	}
+	return cloud, nil
+}
+
+// distanceInMeters converts the radial distance chunk into meters
+func distanceInMeters(distance *Chunk, resolution float32) (*Image[float32], error) {
+	switch distance.dataFormat {
+	case FORMAT_32F:
+		return ImageFromChunk[float32](distance)
+	case FORMAT_16U:
+		img, err := ConvertedImageFromChunk[float32](distance)
+		if err != nil {
+			return nil, err
+		}
+		for i := range img.pix {
+			img.pix[i] *= resolution
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("unsupported radial distance data format: %s", distance.dataFormat)
+}
+
+// Width returns the number of points in a row
+func (pc *PointCloud) Width() int {
+	return pc.width
+}
+
+// Height returns the number of rows
+func (pc *PointCloud) Height() int {
+	return pc.height
+}
+
+// At returns the point of the pixel at the column x and the row y and whether it is valid
+func (pc *PointCloud) At(x, y int) (Point, bool) {
+	if x < 0 || x >= pc.width || y < 0 || y >= pc.height {
+		panic(fmt.Sprintf("pcic: position (%d,%d) out of range %dx%d", x, y, pc.width, pc.height))
+	}
+	i := y*pc.width + x
+	return pc.points[i], pc.valid[i]
+}
+
+// Points returns all points in row major order, invalid points are zero
+func (pc *PointCloud) Points() []Point {
+	return pc.points
+}
+
+// Valid returns the validity of each point in row major order
+func (pc *PointCloud) Valid() []bool {
+	return pc.valid
+}
+
+// ValidPoints returns a copy of the valid points only
+func (pc *PointCloud) ValidPoints() []Point {
+	points := make([]Point, 0, len(pc.points))
+	for i, p := range pc.points {
+		if pc.valid[i] {
+			points = append(points, p)
+		}
+	}
+	return points
+}
@@ -0,0 +1,224 @@
+package pcic_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func uint16Chunk(chunkType pcic.ChunkType, width, height int, values []uint16) *pcic.Chunk {
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(chunkType),
+		pcic.WithDimension(width, height, pcic.FORMAT_16U),
+	)
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(chunk.Bytes()[2*i:], v)
+	}
+	return chunk
+}
+
+func pointCloudFrame(t *testing.T) pcic.Frame {
+	info := testTOFInfo()
+	info.DistanceResolution = 0.001
+	// Rotate by 90 degrees around Z and move 1m up
+	info.Extrinsic = [6]float32{0, 0, 1, 0, 0, math.Pi / 2}
+	return pcic.Frame{Chunks: []pcic.Chunk{
+		*uint16Chunk(pcic.RADIAL_DISTANCE_IMAGE, 2, 2, []uint16{1000, 2000, 0, 4000}),
+		*typedFloat32Chunk(pcic.UNIT_VECTOR_ALL, 6, 2, []float32{
+			1, 0, 0,
+			0, 1, 0,
+			0, 0, 1,
+			0.6, 0, 0.8,
+		}),
+		*tofInfoChunk(t, info, 428),
+	}}
+}
+
+// pointTolerance is the maximum deviation of a point component in meters
+const pointTolerance = 1e-4
+
+func assertPoint(t *testing.T, expected, actual pcic.Point) {
+	assert.InDelta(t, expected.X, actual.X, pointTolerance, "A X component mismatch occurred")
+	assert.InDelta(t, expected.Y, actual.Y, pointTolerance, "A Y component mismatch occurred")
+	assert.InDelta(t, expected.Z, actual.Z, pointTolerance, "A Z component mismatch occurred")
+}
+
+func TestPointCloudFromFrame(t *testing.T) {
+	frame := pointCloudFrame(t)
+	cloud, err := pcic.PointCloudFromFrame(&frame)
+	assert.NoError(t, err, "We expect no error while computing the point cloud")
+	assert.Equal(t, 2, cloud.Width(), "A width mismatch occurred")
+	assert.Equal(t, 2, cloud.Height(), "A height mismatch occurred")
+
+	p, valid := cloud.At(0, 0)
+	assert.True(t, valid, "We expect the point to be valid")
+	assertPoint(t, pcic.Point{X: 0, Y: 1, Z: 1}, p)
+	p, _ = cloud.At(1, 0)
+	assertPoint(t, pcic.Point{X: -2, Y: 0, Z: 1}, p)
+	p, valid = cloud.At(0, 1)
+	assert.False(t, valid, "We expect a pixel without distance to be invalid")
+	assert.Equal(t, pcic.Point{}, p, "We expect an invalid point to be zero")
+	p, _ = cloud.At(1, 1)
+	assertPoint(t, pcic.Point{X: 0, Y: 2.4, Z: 4.2}, p)
+
+	assert.Equal(t, []bool{true, true, false, true}, cloud.Valid(), "A validity mismatch occurred")
+	assert.Equal(t, 3, len(cloud.ValidPoints()), "A valid point count mismatch occurred")
+	assert.Panics(t, func() { cloud.At(2, 0) }, "We expect a panic when out of range")
+}
+
+func TestPointCloudConfidence(t *testing.T) {
+	frame := pointCloudFrame(t)
+	frame.Chunks = append(frame.Chunks,
+		*uint16Chunk(pcic.CONFIDENCE_IMAGE, 2, 2, []uint16{0x00, 0x01, 0x00, 0x80}),
+	)
+	cloud, err := pcic.PointCloudFromFrame(&frame)
+	assert.NoError(t, err, "We expect no error while computing the point cloud")
+	assert.Equal(t, []bool{true, false, false, true}, cloud.Valid(), "A validity mismatch occurred")
+
+	cloud, err = pcic.PointCloudFromFrame(&frame,
+		pcic.WithRejectedFlags(pcic.CONFIDENCE_INVALID|pcic.CONFIDENCE_SUSPECT),
+	)
+	assert.NoError(t, err, "We expect no error while computing the point cloud")
+	assert.Equal(t, []bool{true, false, false, false}, cloud.Valid(), "A validity mismatch occurred")
+
+	distance, _ := frame.ChunkByType(pcic.RADIAL_DISTANCE_IMAGE)
+	unitVectors, _ := frame.ChunkByType(pcic.UNIT_VECTOR_ALL)
+	tofInfo, _ := frame.ChunkByType(pcic.TOF_INFO)
+	info, err := pcic.TOFInfoFromChunk(tofInfo)
+	assert.NoError(t, err, "We expect no error while decoding the TOF_INFO chunk")
+	_, err = pcic.PointCloudFromChunks(distance, unitVectors, info,
+		pcic.WithConfidence(uint16Chunk(pcic.CONFIDENCE_IMAGE, 1, 1, []uint16{0})),
+	)
+	assert.Error(t, err, "We expect an error due to a dimension mismatch")
+}
+
+func TestPointCloudMetricDistance(t *testing.T) {
+	frame := pointCloudFrame(t)
+	frame.Chunks[0] = *typedFloat32Chunk(pcic.RADIAL_DISTANCE_IMAGE, 2, 2, []float32{1, 2, 0, 4})
+	cloud, err := pcic.PointCloudFromFrame(&frame)
+	assert.NoError(t, err, "We expect no error while computing the point cloud")
+	p, _ := cloud.At(1, 1)
+	assertPoint(t, pcic.Point{X: 0, Y: 2.4, Z: 4.2}, p)
+}
+
+func TestPointCloudMissingChunks(t *testing.T) {
+	frame := pointCloudFrame(t)
+	frame.Chunks = frame.Chunks[:2]
+	_, err := pcic.PointCloudFromFrame(&frame)
+	assert.Error(t, err, "We expect an error due to a missing TOF_INFO chunk")
+
+	frame = pointCloudFrame(t)
+	frame.Chunks[1] = *typedFloat32Chunk(pcic.UNIT_VECTOR_ALL, 3, 1, []float32{1, 0, 0})
+	_, err = pcic.PointCloudFromFrame(&frame)
+	assert.Error(t, err, "We expect an error due to a unit vector count mismatch")
+}
+
+// fixtureFrames returns the frames of the synthetic point cloud fixture,
+// see scripts/pointcloud-fixture.py
+func fixtureFrames(t *testing.T) []pcic.Frame {
+	data, err := tfs.ReadFile("testdata/pcic-pointcloud.blob")
+	assert.NoError(t, err, "No error expected while reading the fixture")
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	handler := &PCICAsyncReceiver{}
+	frames := []pcic.Frame{}
+	for {
+		err := p.ProcessIncomming(handler)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !assert.NoError(t, err, "No error expected while reading the fixture") {
+			break
+		}
+		frames = append(frames, handler.frame)
+	}
+	assert.Equal(t, 2, len(frames), "A frame count mismatch occurred")
+	return frames
+}
+
+// TestPointCloudWithRecordedData compares the point cloud with the Cartesian
+// chunks computed by the device, it is skipped without the Git LFS test data.
+func TestPointCloudWithRecordedData(t *testing.T) {
+	data := recordedMessages(t, "testdata/pcic-test-data.blob.bz2")
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	handler := &PCICAsyncReceiver{}
+	compared := 0
+	for {
+		err := p.ProcessIncomming(handler)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err, "No error expected while reading the recorded data")
+		frame := handler.frame
+		if !frame.Has(pcic.RADIAL_DISTANCE_IMAGE, pcic.UNIT_VECTOR_ALL, pcic.TOF_INFO,
+			pcic.CARTESIAN_X_COMPONENT, pcic.CARTESIAN_Y_COMPONENT, pcic.CARTESIAN_Z_COMPONENT) {
+			continue
+		}
+		cloud, err := pcic.PointCloudFromFrame(&frame)
+		assert.NoError(t, err, "We expect no error while computing the point cloud")
+		components := [3]*pcic.Image[float32]{}
+		for i, chunkType := range []pcic.ChunkType{
+			pcic.CARTESIAN_X_COMPONENT, pcic.CARTESIAN_Y_COMPONENT, pcic.CARTESIAN_Z_COMPONENT,
+		} {
+			chunk, _ := frame.ChunkByType(chunkType)
+			components[i], err = pcic.ImageFromChunk[float32](chunk)
+			assert.NoError(t, err, "We expect no error while decoding the %s chunk", chunkType)
+		}
+		for i, point := range cloud.Points() {
+			if !cloud.Valid()[i] {
+				continue
+			}
+			assertPoint(t, pcic.Point{
+				X: components[0].Pix()[i],
+				Y: components[1].Pix()[i],
+				Z: components[2].Pix()[i],
+			}, point)
+		}
+		compared++
+	}
+	if compared == 0 {
+		t.Skip("The recorded data contains no frame with distance, unit vectors and Cartesian data")
+	}
+}
+
+// TestPointCloudWithCartesianFixture checks the decoding of a complete synthetic
+// stream including the invalid pixels. The fixture follows the same conventions
+// as PointCloudFromFrame, the device output is checked by TestPointCloudWithRecordedData.
+func TestPointCloudWithCartesianFixture(t *testing.T) {
+	for _, frame := range fixtureFrames(t) {
+		cloud, err := pcic.PointCloudFromFrame(&frame)
+		assert.NoError(t, err, "We expect no error while computing the point cloud")
+		components := [3]*pcic.Image[float32]{}
+		for i, chunkType := range []pcic.ChunkType{
+			pcic.CARTESIAN_X_COMPONENT, pcic.CARTESIAN_Y_COMPONENT, pcic.CARTESIAN_Z_COMPONENT,
+		} {
+			chunk, ok := frame.ChunkByType(chunkType)
+			assert.True(t, ok, "We expect the fixture to contain the %s chunk", chunkType)
+			components[i], err = pcic.ImageFromChunk[float32](chunk)
+			assert.NoError(t, err, "We expect no error while decoding the %s chunk", chunkType)
+		}
+		// The pixel 5 has no distance and the pixel 17 is marked invalid
+		assert.Equal(t, 8*6-2, len(cloud.ValidPoints()), "A valid point count mismatch occurred")
+		assert.False(t, cloud.Valid()[5], "We expect a pixel without distance to be invalid")
+		assert.False(t, cloud.Valid()[17], "We expect the confidence to be applied")
+		for i, point := range cloud.Points() {
+			assertPoint(t, pcic.Point{
+				X: components[0].Pix()[i],
+				Y: components[1].Pix()[i],
+				Z: components[2].Pix()[i],
+			}, point)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package pcic
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// messageBuffer holds the content of a single message and the chunks referencing it
+type messageBuffer struct {
+	data   []byte
+	chunks []Chunk
+	lease  atomic.Uint64 // Incremented on each release, this way stale frames can not release the buffer again
+}
+
+// messagePool is shared by all clients so the buffers are reused across several devices
+var messagePool = sync.Pool{
+	New: func() any {
+		return &messageBuffer{}
+	},
+}
+
+// WithBufferPool is a PCICClientOption which decodes the messages into pooled buffers.
+//
+// The chunks of the frames passed to MessageHandler.Result reference the pooled
+// buffer instead of owning a copy of their data. The handler takes over the
+// ownership of the Frame and has to call Frame.Release once it is done with it.
+// Use Frame.Clone to keep the data beyond that point.
+func WithBufferPool() PCICClientOption {
+	return func(c *PCICClient) error {
+		c.pooled = true
+		return nil
+	}
+}
+
+// acquireMessageBuffer returns a buffer with room for size bytes
+func acquireMessageBuffer(size int) *messageBuffer {
+	buffer := messagePool.Get().(*messageBuffer)
+	if cap(buffer.data) < size {
+		buffer.data = make([]byte, size)
+	}
+	buffer.data = buffer.data[:size]
+	buffer.chunks = buffer.chunks[:0]
+	return buffer
+}
+
+// release puts the buffer back into the pool, it is safe to call on nil.
+//
+// The buffer is only put back in case the lease is the current one, which
+// makes sure it is put back once for each time it was acquired.
+func (b *messageBuffer) release(lease uint64) {
+	if b == nil || !b.lease.CompareAndSwap(lease, lease+1) {
+		return
+	}
+	clear(b.chunks)
+	messagePool.Put(b)
+}
+
+// discard releases the buffer which was not handed over to a Frame, it is safe to call on nil
+func (b *messageBuffer) discard() {
+	b.release(b.current())
+}
+
+// current returns the lease of the buffer, it is safe to call on nil
+func (b *messageBuffer) current() uint64 {
+	if b == nil {
+		return 0
+	}
+	return b.lease.Load()
+}
+
+// frame parses the chunks as views into the buffer, the Frame owns the buffer
+func (b *messageBuffer) frame() (Frame, error) {
+	chunks, err := chunkParser(b.data, b.chunks[:0], (*Chunk).unmarshalView)
+	b.chunks = chunks
+	return Frame{Chunks: chunks, buffer: b, lease: b.current()}, err
+}
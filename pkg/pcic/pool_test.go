@@ -0,0 +1,182 @@
+package pcic_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// PCICReleasingReceiver releases every pooled frame it receives
+type PCICReleasingReceiver struct {
+	PCICAsyncReceiver
+	frames int
+}
+
+func (r *PCICReleasingReceiver) Result(frame pcic.Frame) {
+	r.frames++
+	frame.Release()
+}
+
+// syntheticMessages creates count result messages with three 224x172 chunks each
+func syntheticMessages(t testing.TB, count int) []byte {
+	content := bytes.Buffer{}
+	for _, format := range []pcic.DataFormat{pcic.FORMAT_32F, pcic.FORMAT_16U, pcic.FORMAT_8U} {
+		chunk := pcic.NewChunk(
+			pcic.WithChunkType(pcic.RADIAL_DISTANCE_NOISE),
+			pcic.WithDimension(224, 172, format),
+		)
+		data, err := chunk.MarshalBinary()
+		assert.NoError(t, err, "No error expected when marshalling to binary")
+		content.Write(data)
+	}
+	messages := bytes.Buffer{}
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&messages,
+			"0000L%09d\r\n0000star%sstop\r\n",
+			miniMalContentLength+content.Len(),
+			content.String(),
+		)
+	}
+	return messages.Bytes()
+}
+
+// recordedMessages returns the decompressed test data or skips in case it is not available
+func recordedMessages(b testing.TB, name string) []byte {
+	file, err := tfs.Open(name)
+	if err != nil {
+		b.Skipf("The test data %s is not available: %v", name, err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(bzip2.NewReader(file))
+	if err != nil {
+		b.Skipf("The test data %s can not be decompressed, is Git LFS installed? %v", name, err)
+	}
+	return data
+}
+
+func processAll(b *testing.B, data []byte, options ...pcic.PCICClientOption) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	handler := &PCICReleasingReceiver{}
+	reader := bytes.NewReader(data)
+	buffered := bufio.NewReader(reader)
+	for i := 0; i < b.N; i++ {
+		reader.Reset(data)
+		buffered.Reset(reader)
+		p, err := pcic.NewPCICClient(append(
+			[]pcic.PCICClientOption{pcic.WithBufioReaderWriter(bufio.NewReadWriter(buffered, nil))},
+			options...,
+		)...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for {
+			err := p.ProcessIncomming(handler)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestPooledReceive(t *testing.T) {
+	data := syntheticMessages(t, 3)
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+		pcic.WithBufferPool(),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	handler := &PCICAsyncReceiver{}
+	assert.NoError(t, p.ProcessIncomming(handler), "No error expected while receiving data")
+	frame := handler.frame
+	assert.Equal(t, 3, len(frame.Chunks), "A chunk count mismatch occurred")
+	assert.Equal(t, 224*172*4, len(frame.Chunks[0].Bytes()), "A data size mismatch occurred")
+	clone := frame.Clone()
+	frame.Release()
+	assert.Nil(t, frame.Chunks, "We expect the chunks to be gone after the release")
+	assert.Equal(t, 3, len(clone.Chunks), "We expect the clone to keep the chunks")
+	// The clone does not reference the pooled buffer, so the release is a no-op
+	clone.Release()
+	assert.Equal(t, 3, len(clone.Chunks), "We expect the clone to keep the chunks")
+
+	// The next message reuses the buffer
+	assert.NoError(t, p.ProcessIncomming(handler), "No error expected while receiving data")
+	assert.Equal(t, 3, len(handler.frame.Chunks), "A chunk count mismatch occurred")
+	handler.frame.Release()
+}
+
+// valueMessage creates a result message with a single 1x1 chunk holding the value
+func valueMessage(t *testing.T, value byte) []byte {
+	chunk := pcic.NewChunk(pcic.WithDimension(1, 1, pcic.FORMAT_8U))
+	chunk.Bytes()[0] = value
+	data, err := chunk.MarshalBinary()
+	assert.NoError(t, err, "No error expected when marshalling to binary")
+	return []byte(fmt.Sprintf("0000L%09d\r\n0000star%sstop\r\n", miniMalContentLength+len(data), data))
+}
+
+func TestPooledReleaseCopies(t *testing.T) {
+	data := bytes.Join([][]byte{valueMessage(t, 1), valueMessage(t, 2), valueMessage(t, 3)}, nil)
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+		pcic.WithBufferPool(),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	handler := &PCICAsyncReceiver{}
+	assert.NoError(t, p.ProcessIncomming(handler), "No error expected while receiving data")
+	frame := handler.frame
+	duplicate := frame
+	frame.Release()
+	duplicate.Release()
+	frame.Release()
+
+	// The buffer must only be handed out once, otherwise both frames share the memory
+	assert.NoError(t, p.ProcessIncomming(handler), "No error expected while receiving data")
+	second := handler.frame
+	assert.NoError(t, p.ProcessIncomming(handler), "No error expected while receiving data")
+	third := handler.frame
+	assert.Equal(t, byte(2), second.Chunks[0].Bytes()[0], "We expect the second frame to keep its data")
+	assert.Equal(t, byte(3), third.Chunks[0].Bytes()[0], "We expect the third frame to keep its data")
+	second.Release()
+	third.Release()
+}
+
+func TestPooledReceiveWithBroker(t *testing.T) {
+	data := syntheticMessages(t, 1)
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+		pcic.WithBufferPool(),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	broker := pcic.NewBroker()
+	sub := broker.Subscribe()
+	defer sub.Close()
+	assert.NoError(t, p.ProcessIncomming(broker), "No error expected while receiving data")
+	frame := <-sub.Frames()
+	assert.Equal(t, 3, len(frame.Chunks), "We expect the subscriber to own a copy of the frame")
+}
+
+func BenchmarkProcessIncommingSynthetic(b *testing.B) {
+	processAll(b, syntheticMessages(b, 20))
+}
+
+func BenchmarkProcessIncommingSyntheticPooled(b *testing.B) {
+	processAll(b, syntheticMessages(b, 20), pcic.WithBufferPool())
+}
+
+func BenchmarkProcessIncommingRecorded(b *testing.B) {
+	processAll(b, recordedMessages(b, "testdata/pcic-test-data.blob.bz2"))
+}
+
+func BenchmarkProcessIncommingRecordedPooled(b *testing.B) {
+	processAll(b, recordedMessages(b, "testdata/pcic-test-data.blob.bz2"), pcic.WithBufferPool())
+}
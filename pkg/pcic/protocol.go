@@ -3,16 +3,36 @@ package pcic
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"strconv"
+	"sync"
+	"time"
 )
 
 type (
 	PCICClient struct {
-		reader *bufio.Reader
-		writer *bufio.Writer
+		reader      *bufio.Reader
+		writer      *bufio.Writer
+		conn        net.Conn            // The underlying connection, nil if a bufio.ReadWriter is provided
+		readTimeout time.Duration       // The maximum time to wait for a message in Run, 0 disables the deadline
+		writeMutex  sync.Mutex          // Serializes the commands written to the device
+		ticketMutex sync.Mutex          // Protects the pending tickets and the ticket counter
+		pending     map[int]chan []byte // The commands waiting for a reply, indexed by ticket
+		abandoned   map[int]time.Time   // The tickets of canceled commands and the time they expire
+		expiry      time.Duration       // The time a canceled command keeps its ticket reserved
+		nextTicket  int                 // The next ticket to be tried for a command
+		closeOnce   sync.Once           // Ensures the connection is only closed once
+		done        chan struct{}       // Closed as soon as the client is closed
+		pooled      bool                // Decode the messages into pooled buffers
+		header      [headerSize]byte    // The buffer the message header is read into
+		logger      *slog.Logger        // Reports the messages and commands at debug level
+		recorder    MessageRecorder     // Receives each raw message, nil if recording is disabled
+		record      []byte              // The buffer the raw message is assembled in for the recorder
 	}
 	PCICClientOption func(c *PCICClient) error
 )
@@ -38,11 +58,24 @@ const (
 	endMarker   string = "stop"
 )
 
+const (
+	messageIDFieldLength int  = 9
+	messageIDSeparator   byte = ':'
+)
+
 var (
-	resultTicket []byte = []byte{'0', '0', '0', '0'}
-	errorTicket  []byte = []byte{'0', '0', '0', '1'}
+	resultTicket       []byte = []byte{'0', '0', '0', '0'}
+	errorTicket        []byte = []byte{'0', '0', '0', '1'}
+	notificationTicket []byte = []byte{'0', '0', '1', '0'}
 )
 
+// MessageRecorder receives the raw PCIC messages including the header and the trailer.
+//
+// The message is only valid during the call and must be copied to be retained.
+type MessageRecorder interface {
+	RecordMessage(received time.Time, message []byte) error
+}
+
 type MessageHandler interface {
 	Result(Frame)
 	Error(ErrorMessage)
@@ -61,7 +94,14 @@ type ErrorMessage struct {
 
 func NewPCICClient(options ...PCICClientOption) (*PCICClient, error) {
 	var err error
-	pcic := &PCICClient{}
+	pcic := &PCICClient{
+		pending:    make(map[int]chan []byte),
+		abandoned:  make(map[int]time.Time),
+		expiry:     defaultTicketExpiry,
+		nextTicket: minCommandTicket,
+		done:       make(chan struct{}),
+		logger:     slog.Default(),
+	}
 	// Apply options
 	for _, opt := range options {
 		if err = opt(pcic); err != nil {
@@ -84,22 +124,73 @@ func WithBufioReaderWriter(com *bufio.ReadWriter) PCICClientOption {
 // If an error occurs during the connection establishment, it will be handled and returned.
 func WithTCPClient(hostname string, port uint16) PCICClientOption {
 	return func(c *PCICClient) error {
-		conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", hostname, port))
+		conn, err := net.Dial("tcp", net.JoinHostPort(hostname, strconv.Itoa(int(port))))
 		if err != nil {
 			return err
 		}
+		return WithConn(conn)(c)
+	}
+}
+
+// WithConn is a PCICClientOption that uses an already established connection.
+// The connection is closed when the PCICClient is closed.
+func WithConn(conn net.Conn) PCICClientOption {
+	return func(c *PCICClient) error {
+		c.conn = conn
 		c.reader = bufio.NewReader(conn)
 		c.writer = bufio.NewWriter(conn)
 		return nil
 	}
 }
 
+// WithLogger is a PCICClientOption that sets the logger used to report the
+// received messages and the sent commands at debug level. By default
+// slog.Default() is used.
+func WithLogger(logger *slog.Logger) PCICClientOption {
+	return func(c *PCICClient) error {
+		if logger == nil {
+			return errors.New("no logger provided")
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithRecorder is a PCICClientOption that hands each raw message read by
+// ProcessIncomming to the recorder before it is dispatched, see Recorder.
+func WithRecorder(recorder MessageRecorder) PCICClientOption {
+	return func(c *PCICClient) error {
+		c.recorder = recorder
+		return nil
+	}
+}
+
+// WithTicketExpiry is a PCICClientOption that sets the time the ticket of a
+// canceled command stays reserved for its late reply. Once expired the ticket
+// is reused, a reply arriving afterwards may be taken for the answer of a new
+// command. By default the ticket is reserved for 30 seconds.
+func WithTicketExpiry(expiry time.Duration) PCICClientOption {
+	return func(c *PCICClient) error {
+		c.expiry = expiry
+		return nil
+	}
+}
+
+// WithReadTimeout is a PCICClientOption that sets the maximum time Run waits for
+// the next message. The deadline is only applied when the client owns a net.Conn.
+func WithReadTimeout(timeout time.Duration) PCICClientOption {
+	return func(c *PCICClient) error {
+		c.readTimeout = timeout
+		return nil
+	}
+}
+
 func (p *PCICClient) ProcessIncomming(handler MessageHandler) error {
 	reader := p.reader
 	if reader == nil {
 		return errors.New("no bufio.Reader provided, please instantiate the object")
 	}
-	header := make([]byte, headerSize)
+	header := p.header[:]
 	_, err := io.ReadFull(reader, header)
 	if err != nil {
 		return err
@@ -112,38 +203,94 @@ func (p *PCICClient) ProcessIncomming(handler MessageHandler) error {
 			string(secondTicket),
 		)
 	}
-	lengthBuffer := string(header[lengthOffset:secondTicketOffset])
-	if lengthBuffer[0] != 'L' {
-		return fmt.Errorf("the length field does not start with 'L': %v", lengthBuffer)
-	}
-	length := 0
-	_, err = fmt.Sscanf(lengthBuffer, "L%09d\r\n", &length)
+	length, err := lengthParser(header[lengthOffset:secondTicketOffset])
 	if err != nil {
 		return err
 	}
 	if length < minimumContentLength {
 		return errors.New("the length information is too short")
 	}
-	data := make([]byte, length-ticketFieldLength)
+	// Avoid the allocations for the attributes in case debug logging is disabled
+	if p.logger.Enabled(context.Background(), slog.LevelDebug) {
+		p.logger.Debug("PCIC message received",
+			slog.String("ticket", string(firstTicket)),
+			slog.Int("length", length),
+		)
+	}
+	var buffer *messageBuffer
+	var data []byte
+	if p.pooled {
+		buffer = acquireMessageBuffer(length - ticketFieldLength)
+		data = buffer.data
+	} else {
+		data = make([]byte, length-ticketFieldLength)
+	}
 	if _, err = io.ReadFull(reader, data); err != nil {
+		buffer.discard()
 		return err
 	}
 	trailer := data[len(data)-delimiterFieldLength:]
 	if !bytes.Equal(trailer, []byte{'\r', '\n'}) {
+		buffer.discard()
 		return errors.New("invalid trailer detected")
 	}
+	if p.recorder != nil {
+		p.record = append(append(p.record[:0], header...), data...)
+		if err := p.recorder.RecordMessage(time.Now(), p.record); err != nil {
+			buffer.discard()
+			return fmt.Errorf("unable to record the message: %w", err)
+		}
+	}
 	if bytes.Equal(resultTicket, firstTicket) {
+		if buffer != nil {
+			// The frame takes over the ownership of the buffer
+			frame, err := buffer.frame()
+			handler.Result(frame)
+			return err
+		}
 		frame, err := asyncResultParser(data)
 		handler.Result(frame)
 		return err
-	} else if bytes.Equal(errorTicket, firstTicket) {
+	}
+	// Only result frames keep a reference to the buffer,
+	// the content of a reply is copied before it is handed over.
+	defer buffer.discard()
+	if bytes.Equal(errorTicket, firstTicket) {
 		errorStatus, err := errorParser(data)
 		handler.Error(errorStatus)
 		return err
+	} else if bytes.Equal(notificationTicket, firstTicket) {
+		notification, err := notificationParser(data)
+		if err != nil {
+			return err
+		}
+		handler.Notification(notification)
+		return nil
+	} else if p.dispatchReply(firstTicket, bytes.Clone(data[:len(data)-delimiterFieldLength])) {
+		return nil
 	}
 	return fmt.Errorf("unknown ticket received: %s", string(firstTicket))
 }
 
+// lengthParser parses the length field of the form "L000000014\r\n"
+// without allocating any memory.
+func lengthParser(field []byte) (int, error) {
+	if field[0] != 'L' {
+		return 0, fmt.Errorf("the length field does not start with 'L': %v", string(field))
+	}
+	if !bytes.Equal(field[lengthFieldLength:], []byte{'\r', '\n'}) {
+		return 0, fmt.Errorf("the length field is not terminated by CR LF: %v", string(field))
+	}
+	length := 0
+	for _, digit := range field[1:lengthFieldLength] {
+		if digit < '0' || digit > '9' {
+			return 0, fmt.Errorf("the length field contains an invalid digit: %v", string(field))
+		}
+		length = length*10 + int(digit-'0')
+	}
+	return length, nil
+}
+
 func errorParser(data []byte) (ErrorMessage, error) {
 	var err error
 	errorStatus := ErrorMessage{}
@@ -159,28 +306,53 @@ func errorParser(data []byte) (ErrorMessage, error) {
 	return errorStatus, err
 }
 
+// notificationParser parses the content of an asynchronous notification message.
+//
+// A notification consists of a 9 digit message ID followed by a colon and
+// a JSON payload, e.g. "000500000:{...}".
+func notificationParser(data []byte) (NotificationMessage, error) {
+	content := data[:len(data)-delimiterFieldLength]
+	if len(content) <= messageIDFieldLength || content[messageIDFieldLength] != messageIDSeparator {
+		return NotificationMessage{}, errors.New("unable to parse the notification message")
+	}
+	id, err := strconv.Atoi(string(content[:messageIDFieldLength]))
+	if err != nil {
+		return NotificationMessage{}, fmt.Errorf("unable to parse the notification ID: %w", err)
+	}
+	return NotificationMessage{
+		ID:      id,
+		Message: string(content[messageIDFieldLength+1:]),
+	}, nil
+}
+
 func asyncResultParser(data []byte) (Frame, error) {
-	fmt.Printf("Async Data received\n")
-	frame := Frame{}
-	var err error
-	contentDecorated := data[:len(data)-delimiterFieldLength]
-	content := contentDecorated[len(endMarker) : len(contentDecorated)-len(endMarker)]
-	if len(content) == 0 {
-		// no content is available
-		return frame, nil
+	chunks, err := chunkParser(data, nil, (*Chunk).UnmarshalBinary)
+	return Frame{Chunks: chunks}, err
+}
+
+// chunkParser parses the chunks of a result message and appends them to chunks
+func chunkParser(data []byte, chunks []Chunk, unmarshal func(*Chunk, []byte) error) ([]Chunk, error) {
+	if len(data) < len(startMarker)+len(endMarker)+delimiterFieldLength {
+		return chunks, errors.New("the result message is too short")
 	}
+	contentDecorated := data[:len(data)-delimiterFieldLength]
+	content := contentDecorated[len(startMarker) : len(contentDecorated)-len(endMarker)]
+	return chunkContentParser(content, chunks, unmarshal)
+}
+
+// chunkContentParser parses the chunks between the start and the end marker
+func chunkContentParser(content []byte, chunks []Chunk, unmarshal func(*Chunk, []byte) error) ([]Chunk, error) {
 	remainingBytes := len(content)
 	offset := 0
 	for remainingBytes > 0 {
-		c := Chunk{}
-		if err := c.UnmarshalBinary(content[offset:]); err != nil {
-			return frame, err
+		// Parse in place, this avoids a heap allocation per chunk
+		chunks = append(chunks, Chunk{})
+		c := &chunks[len(chunks)-1]
+		if err := unmarshal(c, content[offset:]); err != nil {
+			return chunks[:len(chunks)-1], err
 		}
-		frame.Chunks = append(frame.Chunks, c)
 		offset += c.Size()
 		remainingBytes -= c.Size()
-
 	}
-	return frame, err
-
+	return chunks, nil
 }
@@ -2,11 +2,13 @@ package pcic_test
 
 import (
 	"bufio"
+	"bytes"
 	"compress/bzip2"
 	"embed"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"strings"
 	"testing"
 
@@ -16,7 +18,7 @@ import (
 
 const miniMalContentLength int = 14
 
-//go:embed testdata/*.bz2
+//go:embed testdata/*.bz2 testdata/*.blob
 var tfs embed.FS
 
 type PCICAsyncReceiver struct {
@@ -367,3 +369,83 @@ func TestWithRealErrorData(t *testing.T) {
 	}
 
 }
+
+func TestWithNotificationData(t *testing.T) {
+	payload := `{"port": "port2", "state": "CONF"}`
+	content := fmt.Sprintf("000500000:%s", payload)
+	buffer := fmt.Sprintf(
+		"0010L%09d\r\n0010%s\r\n",
+		len(content)+6,
+		content,
+	)
+	// Test the PCIC message with notification message
+	readerWriter := bufio.NewReadWriter(
+		bufio.NewReader(strings.NewReader(buffer)),
+		nil,
+	)
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(readerWriter),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	err = p.ProcessIncomming(testHandler)
+	assert.NoError(t,
+		err,
+		"We expect no error while receiving a notification",
+	)
+	assert.Equal(t,
+		500000,
+		testHandler.notificationMsg.ID,
+		"A notification ID mismatch occurred",
+	)
+	assert.Equal(t,
+		payload,
+		testHandler.notificationMsg.Message,
+		"A notification payload mismatch occurred",
+	)
+}
+
+func TestWithMalformedNotificationData(t *testing.T) {
+	for _, content := range []string{"", "00050000:{}", "000500000{}", "00050000X:{}"} {
+		buffer := fmt.Sprintf(
+			"0010L%09d\r\n0010%s\r\n",
+			len(content)+6,
+			content,
+		)
+		readerWriter := bufio.NewReadWriter(
+			bufio.NewReader(strings.NewReader(buffer)),
+			nil,
+		)
+		p, err := pcic.NewPCICClient(
+			pcic.WithBufioReaderWriter(readerWriter),
+		)
+		assert.NoError(t, err, "We expect no error while creating the PCICClient")
+		err = p.ProcessIncomming(testHandler)
+		assert.Error(t,
+			err,
+			"We expect an error while receiving malformed notification: %q",
+			content,
+		)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	output := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&output, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	readerWriter := bufio.NewReadWriter(
+		bufio.NewReader(strings.NewReader("0000L000000014\r\n0000starstop\r\n")),
+		nil,
+	)
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(readerWriter),
+		pcic.WithLogger(logger),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	assert.NoError(t, p.ProcessIncomming(testHandler), "We expect no error while receiving data")
+	assert.Contains(t, output.String(), "ticket=0000", "We expect the ticket to be logged")
+	assert.Contains(t, output.String(), "length=14", "We expect the length to be logged")
+}
+
+func TestWithNilLogger(t *testing.T) {
+	_, err := pcic.NewPCICClient(pcic.WithLogger(nil))
+	assert.Error(t, err, "We expect an error for a nil logger")
+}
@@ -0,0 +1,346 @@
+package pcic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// ConnectionState describes the state of the connection of a ReconnectingClient
+	ConnectionState int
+
+	// ConnectionStateHandler can be implemented by a MessageHandler to be
+	// informed about the connection state changes of a ReconnectingClient.
+	ConnectionStateHandler interface {
+		ConnectionState(ConnectionState)
+	}
+
+	// ReconnectingClient is a PCIC client which redials the device whenever the connection is lost.
+	//
+	// The output schema and the result output state configured through the client are
+	// re-issued after each reconnect. An unplugged cable is only detected in case a
+	// read timeout is set, see WithClientOptions and WithReadTimeout.
+	ReconnectingClient struct {
+		address        string                 // The address of the device in the form host:port
+		initialBackoff time.Duration          // The delay before the first reconnect attempt
+		maxBackoff     time.Duration          // The upper limit of the delay between two attempts
+		backoffFactor  float64                // The factor the delay grows after each failed attempt
+		states         chan<- ConnectionState // An optional channel the state changes are sent to
+		clientOptions  []PCICClientOption     // Additional options for each connection
+		mutex          sync.Mutex             // Protects the fields below
+		client         *PCICClient            // The current connection, nil while disconnected
+		schema         *OutputSchema          // The output schema to be restored
+		resultOutput   *bool                  // The result output state to be restored
+		closeOnce      sync.Once              // Ensures the client is only closed once
+		done           chan struct{}          // Closed as soon as the client is closed
+	}
+	ReconnectingClientOption func(r *ReconnectingClient)
+)
+
+// The states of the connection
+const (
+	StateConnecting ConnectionState = iota // A connection attempt is in progress
+	StateConnected                         // The connection is established and the configuration is restored
+	StateLost                              // The connection was lost or the attempt failed
+	StateFailed                            // The device rejected the configuration, Run returns the error
+)
+
+// The default backoff parameters
+const (
+	defaultInitialBackoff time.Duration = 500 * time.Millisecond
+	defaultMaxBackoff     time.Duration = 30 * time.Second
+	defaultBackoffFactor  float64       = 2.0
+)
+
+// ErrNotConnected is returned when a command is sent while the device is not connected
+var ErrNotConnected = errors.New("the device is not connected")
+
+func (s ConnectionState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateLost:
+		return "lost"
+	case StateFailed:
+		return "failed"
+	}
+	return "unknown"
+}
+
+// NewReconnectingClient creates a client for the given device, the connection
+// is established once Run is called.
+func NewReconnectingClient(hostname string, port uint16, options ...ReconnectingClientOption) *ReconnectingClient {
+	client := &ReconnectingClient{
+		address:        net.JoinHostPort(hostname, strconv.Itoa(int(port))),
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		backoffFactor:  defaultBackoffFactor,
+		done:           make(chan struct{}),
+	}
+	// Apply options
+	for _, opt := range options {
+		opt(client)
+	}
+	return client
+}
+
+// WithBackoff sets the exponential backoff used between the connection attempts.
+// The delay starts with initial and is multiplied by factor after each failed
+// attempt until maximum is reached.
+//
+// Invalid values are clamped in order not to busy-spin: a non-positive initial
+// delay and a factor below 1 fall back to the defaults, a maximum below the
+// initial delay is raised to the initial delay.
+func WithBackoff(initial, maximum time.Duration, factor float64) ReconnectingClientOption {
+	return func(r *ReconnectingClient) {
+		if initial <= 0 {
+			initial = defaultInitialBackoff
+		}
+		if !(factor >= 1) || math.IsInf(factor, 1) {
+			factor = defaultBackoffFactor
+		}
+		if maximum < initial {
+			maximum = initial
+		}
+		r.initialBackoff = initial
+		r.maxBackoff = maximum
+		r.backoffFactor = factor
+	}
+}
+
+// WithStateChannel sets a channel the connection state changes are sent to.
+//
+// The states are sent without blocking, a state is dropped in case the
+// channel is full. Use a buffered channel in order not to miss any change.
+func WithStateChannel(states chan<- ConnectionState) ReconnectingClientOption {
+	return func(r *ReconnectingClient) {
+		r.states = states
+	}
+}
+
+// WithClientOptions sets additional options applied to each connection, e.g. WithReadTimeout
+func WithClientOptions(options ...PCICClientOption) ReconnectingClientOption {
+	return func(r *ReconnectingClient) {
+		r.clientOptions = append(r.clientOptions, options...)
+	}
+}
+
+// Run connects to the device and processes the incoming messages until the
+// context is canceled or Close is called. Whenever the connection is lost
+// it is redialed after the backoff delay.
+//
+// The state changes are reported to the state channel and to the handler in case
+// it implements the ConnectionStateHandler interface. Run returns the error of
+// the context, ErrClientClosed or a CommandError in case the device rejects the
+// restored configuration, redialing would not help in this case.
+func (r *ReconnectingClient) Run(ctx context.Context, handler MessageHandler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-r.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	backoff := r.initialBackoff
+	for {
+		r.reportState(handler, StateConnecting)
+		connected, err := r.runConnection(ctx, handler)
+		if ctx.Err() != nil {
+			return r.terminalError(ctx)
+		}
+		if err != nil {
+			r.reportState(handler, StateFailed)
+			return err
+		}
+		if connected {
+			// The connection was established, start over with the initial delay
+			backoff = r.initialBackoff
+		}
+		r.reportState(handler, StateLost)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return r.terminalError(ctx)
+		}
+		backoff = time.Duration(float64(backoff) * r.backoffFactor)
+		if backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
+
+// runConnection dials the device, restores the configuration and processes
+// the messages until the connection is lost. It returns true in case the
+// connection was established successfully. The returned error is not nil
+// in case the device rejected the restored configuration.
+func (r *ReconnectingClient) runConnection(ctx context.Context, handler MessageHandler) (bool, error) {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", r.address)
+	if err != nil {
+		return false, nil
+	}
+	options := append([]PCICClientOption{WithConn(conn)}, r.clientOptions...)
+	client, err := NewPCICClient(options...)
+	if err != nil {
+		conn.Close()
+		return false, nil
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Run(ctx, handler)
+	}()
+	if err := r.restore(ctx, client); err != nil {
+		client.Close()
+		<-result
+		var commandErr *CommandError
+		if errors.As(err, &commandErr) {
+			return false, fmt.Errorf("unable to restore the configuration: %w", err)
+		}
+		return false, nil
+	}
+	r.reportState(handler, StateConnected)
+	<-result
+	r.mutex.Lock()
+	r.client = nil
+	r.mutex.Unlock()
+	return true, nil
+}
+
+// restore re-issues the configuration on a new connection and makes it the current one.
+//
+// The lock is not held during the round trips to the device, in case the
+// configuration is changed in the meantime it is restored once more.
+func (r *ReconnectingClient) restore(ctx context.Context, client *PCICClient) error {
+	for {
+		r.mutex.Lock()
+		schema, resultOutput := r.schema, r.resultOutput
+		r.mutex.Unlock()
+		if schema != nil {
+			if err := client.SetSchema(ctx, schema); err != nil {
+				return err
+			}
+		}
+		if resultOutput != nil {
+			if err := client.SetResultOutput(ctx, *resultOutput); err != nil {
+				return err
+			}
+		}
+		r.mutex.Lock()
+		if r.schema == schema && r.resultOutput == resultOutput {
+			r.client = client
+			r.mutex.Unlock()
+			return nil
+		}
+		r.mutex.Unlock()
+	}
+}
+
+// reportState informs the state channel and the handler about the new state
+func (r *ReconnectingClient) reportState(handler MessageHandler, state ConnectionState) {
+	if stateHandler, ok := handler.(ConnectionStateHandler); ok {
+		stateHandler.ConnectionState(state)
+	}
+	if r.states == nil {
+		return
+	}
+	select {
+	case r.states <- state:
+	default:
+		// The consumer does not keep up, do not stall the reconnect
+	}
+}
+
+// terminalError returns ErrClientClosed if the client was closed and the error of the context otherwise
+func (r *ReconnectingClient) terminalError(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return ErrClientClosed
+	default:
+	}
+	return ctx.Err()
+}
+
+// Close ends Run and closes the current connection
+func (r *ReconnectingClient) Close() error {
+	var err error
+	r.closeOnce.Do(func() {
+		close(r.done)
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+		if r.client != nil {
+			err = r.client.Close()
+		}
+	})
+	return err
+}
+
+// current returns the current connection or ErrNotConnected
+func (r *ReconnectingClient) current() (*PCICClient, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.client == nil {
+		return nil, ErrNotConnected
+	}
+	return r.client, nil
+}
+
+// Send transmits a PCIC command on the current connection, see PCICClient.Send
+func (r *ReconnectingClient) Send(ctx context.Context, command []byte) ([]byte, error) {
+	client, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+	return client.Send(ctx, command)
+}
+
+// Trigger sends a software trigger on the current connection
+func (r *ReconnectingClient) Trigger(ctx context.Context) error {
+	client, err := r.current()
+	if err != nil {
+		return err
+	}
+	return client.Trigger(ctx)
+}
+
+// SetSchema uploads the output schema and re-issues it after each reconnect.
+//
+// In case the device is not connected ErrNotConnected is returned, the schema
+// is uploaded as soon as the connection is established.
+func (r *ReconnectingClient) SetSchema(ctx context.Context, schema *OutputSchema) error {
+	if schema == nil {
+		return errors.New("no output schema provided")
+	}
+	r.mutex.Lock()
+	r.schema = schema
+	r.mutex.Unlock()
+	client, err := r.current()
+	if err != nil {
+		return err
+	}
+	return client.SetSchema(ctx, schema)
+}
+
+// SetResultOutput enables or disables the result output and re-issues it after each reconnect.
+//
+// In case the device is not connected ErrNotConnected is returned, the state
+// is set as soon as the connection is established.
+func (r *ReconnectingClient) SetResultOutput(ctx context.Context, enabled bool) error {
+	r.mutex.Lock()
+	r.resultOutput = &enabled
+	r.mutex.Unlock()
+	client, err := r.current()
+	if err != nil {
+		return err
+	}
+	return client.SetResultOutput(ctx, enabled)
+}
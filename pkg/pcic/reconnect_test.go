@@ -0,0 +1,223 @@
+package pcic_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// acceptAndReply accepts a single connection and acknowledges the given
+// number of commands, the received commands are sent to the returned channel.
+func acceptAndReply(listener net.Listener, commands int) (net.Conn, <-chan string) {
+	received := make(chan string, commands)
+	conn, err := listener.Accept()
+	if err != nil {
+		close(received)
+		return nil, received
+	}
+	go func() {
+		reader := bufio.NewReader(conn)
+		for i := 0; i < commands; i++ {
+			ticket, content, err := readCommand(reader)
+			if err != nil {
+				return
+			}
+			received <- content
+			_ = writeMessage(conn, ticket, "*")
+		}
+	}()
+	return conn, received
+}
+
+func expectState(t *testing.T, states <-chan pcic.ConnectionState, expected pcic.ConnectionState) {
+	select {
+	case state := <-states:
+		assert.Equal(t, expected, state, "A connection state mismatch occurred")
+	case <-time.After(5 * time.Second):
+		t.Fatalf("No connection state received, expected: %s", expected)
+	}
+}
+
+func TestReconnectingClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "We expect no error while creating the listener")
+	defer listener.Close()
+	_, portString, _ := net.SplitHostPort(listener.Addr().String())
+	port, _ := strconv.Atoi(portString)
+
+	states := make(chan pcic.ConnectionState, 10)
+	client := pcic.NewReconnectingClient("127.0.0.1", uint16(port),
+		pcic.WithBackoff(time.Millisecond, 10*time.Millisecond, 2),
+		pcic.WithStateChannel(states),
+	)
+	schema := pcic.NewOutputSchema(pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE))
+	assert.ErrorIs(t,
+		client.SetSchema(context.Background(), schema),
+		pcic.ErrNotConnected,
+		"We expect an error while not connected",
+	)
+	assert.ErrorIs(t,
+		client.SetResultOutput(context.Background(), true),
+		pcic.ErrNotConnected,
+		"We expect an error while not connected",
+	)
+
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Run(context.Background(), &PCICAsyncReceiver{})
+	}()
+
+	for i := 0; i < 2; i++ {
+		expectState(t, states, pcic.StateConnecting)
+		conn, received := acceptAndReply(listener, 2)
+		assert.Equal(t, "c", (<-received)[:1], "We expect the schema to be restored")
+		assert.Equal(t, "p1", <-received, "We expect the result output to be restored")
+		expectState(t, states, pcic.StateConnected)
+		// Simulate a reboot of the device
+		conn.Close()
+		expectState(t, states, pcic.StateLost)
+	}
+
+	assert.NoError(t, client.Close(), "We expect no error while closing the client")
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, pcic.ErrClientClosed, "We expect the client to be closed")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return in time")
+	}
+}
+
+func TestReconnectingClientCancel(t *testing.T) {
+	// Nobody listens on this port, so each attempt fails
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "We expect no error while creating the listener")
+	address := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+
+	states := make(chan pcic.ConnectionState, 10)
+	client := pcic.NewReconnectingClient("127.0.0.1", uint16(address.Port),
+		pcic.WithBackoff(time.Millisecond, 5*time.Millisecond, 2),
+		pcic.WithStateChannel(states),
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Run(ctx, &PCICAsyncReceiver{})
+	}()
+	expectState(t, states, pcic.StateConnecting)
+	expectState(t, states, pcic.StateLost)
+	cancel()
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, context.Canceled, "We expect the context error")
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return in time")
+	}
+}
+
+func TestReconnectingClientRestoreRejected(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "We expect no error while creating the listener")
+	defer listener.Close()
+	address := listener.Addr().(*net.TCPAddr)
+
+	states := make(chan pcic.ConnectionState, 10)
+	client := pcic.NewReconnectingClient("127.0.0.1", uint16(address.Port),
+		pcic.WithBackoff(time.Millisecond, 10*time.Millisecond, 2),
+		pcic.WithStateChannel(states),
+	)
+	_ = client.SetResultOutput(context.Background(), true)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ticket, _, err := readCommand(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		_ = writeMessage(conn, ticket, "!")
+	}()
+	err = client.Run(context.Background(), &PCICAsyncReceiver{})
+	assert.ErrorIs(t, err, pcic.ErrCommandFailed, "We expect the rejected command to be reported")
+	expectState(t, states, pcic.StateConnecting)
+	expectState(t, states, pcic.StateFailed)
+}
+
+func TestReconnectingClientStalledStates(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "We expect no error while creating the listener")
+	defer listener.Close()
+	address := listener.Addr().(*net.TCPAddr)
+
+	// Nobody reads the states
+	client := pcic.NewReconnectingClient("127.0.0.1", uint16(address.Port),
+		pcic.WithStateChannel(make(chan pcic.ConnectionState)),
+	)
+	_ = client.SetResultOutput(context.Background(), true)
+	result := make(chan error, 1)
+	go func() {
+		result <- client.Run(context.Background(), &PCICAsyncReceiver{})
+	}()
+	conn, received := acceptAndReply(listener, 1)
+	defer conn.Close()
+	select {
+	case command := <-received:
+		assert.Equal(t, "p1", command, "We expect the result output to be restored")
+	case <-time.After(5 * time.Second):
+		t.Fatal("We expect the connection not to stall")
+	}
+	assert.NoError(t, client.Close(), "We expect no error while closing the client")
+	assert.ErrorIs(t, <-result, pcic.ErrClientClosed, "We expect the client to be closed")
+}
+
+func TestConnectionStateString(t *testing.T) {
+	assert.Equal(t, "connecting", pcic.StateConnecting.String())
+	assert.Equal(t, "connected", pcic.StateConnected.String())
+	assert.Equal(t, "lost", pcic.StateLost.String())
+	assert.Equal(t, "failed", pcic.StateFailed.String())
+	assert.Equal(t, "unknown", pcic.ConnectionState(42).String())
+}
+
+// connectionAttempts counts the connection attempts to a closed port within the given duration
+func connectionAttempts(t *testing.T, duration time.Duration, options ...pcic.ReconnectingClientOption) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "We expect no error while creating the listener")
+	address := listener.Addr().(*net.TCPAddr)
+	listener.Close()
+
+	states := make(chan pcic.ConnectionState, 1000)
+	client := pcic.NewReconnectingClient("127.0.0.1", uint16(address.Port),
+		append(options, pcic.WithStateChannel(states))...,
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+	_ = client.Run(ctx, &PCICAsyncReceiver{})
+	close(states)
+	attempts := 0
+	for state := range states {
+		if state == pcic.StateConnecting {
+			attempts++
+		}
+	}
+	return attempts
+}
+
+func TestReconnectingClientInvalidBackoff(t *testing.T) {
+	assert.Equal(t, 1,
+		connectionAttempts(t, 200*time.Millisecond, pcic.WithBackoff(0, 0, 0.5)),
+		"We expect the default initial delay for a non-positive delay",
+	)
+	assert.LessOrEqual(t,
+		connectionAttempts(t, 200*time.Millisecond, pcic.WithBackoff(50*time.Millisecond, 0, 0.5)),
+		5,
+		"We expect the delay not to shrink below the initial delay",
+	)
+}
@@ -0,0 +1,522 @@
+package pcic
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A recording file starts with the magic, followed by the length of the
+// JSON encoded RecordingHeader and the header itself. The records follow
+// the header, optionally gzip compressed. Each record is flushed to the
+// file, a record truncated by an interrupted recording ends the file. Each
+// record consists of the
+// receive time in nano seconds since the epoch, the length of the message
+// and the raw PCIC message, all integers are little endian.
+const (
+	recordingMagic       string = "PCICREC1"
+	recordingVersion     int    = 1
+	recordingHeaderLimit uint32 = 16 << 20
+	recordHeaderSize     int    = 12
+	maxRecordSize        uint32 = 1 << 30
+)
+
+// The compression methods of a recording
+const (
+	CompressionNone string = "none"
+	CompressionGzip string = "gzip"
+)
+
+// ErrRecorderClosed is returned when a message is recorded after the Recorder is closed
+var ErrRecorderClosed = errors.New("the recorder is closed")
+
+type (
+	// RecordingHeader describes the content of a recording file
+	RecordingHeader struct {
+		Version      int             `json:"version"`                // The version of the file format
+		Created      time.Time       `json:"created"`                // The time the file was created
+		Host         string          `json:"host,omitempty"`         // The host the messages are received from
+		Port         uint16          `json:"port,omitempty"`         // The PCIC port the messages are received from
+		Compression  string          `json:"compression"`            // The compression of the records
+		Sequence     int             `json:"sequence"`               // The index of the file within a rotated recording
+		DeviceConfig json.RawMessage `json:"deviceConfig,omitempty"` // The configuration of the device at the start of the recording
+	}
+
+	// Recorder writes the raw PCIC messages to recording files, see WithRecorder
+	Recorder struct {
+		path        string          // The path of the recording, the sequence is added in case of rotation
+		host        string          // The host the messages are received from
+		port        uint16          // The PCIC port the messages are received from
+		config      json.RawMessage // The device configuration stored in each header
+		compress    bool            // Compress the records with gzip
+		maxSize     int64           // Start a new file once the size is reached, 0 disables the limit
+		maxDuration time.Duration   // Start a new file once the duration is reached, 0 disables the limit
+		mutex       sync.Mutex      // Protects the fields below
+		file        *os.File        // The current file, nil once closed
+		counter     *countingWriter // Counts the bytes written to the current file
+		gzip        *gzip.Writer    // The compressor of the current file, nil if compression is disabled
+		writer      *bufio.Writer   // Buffers the records of the current file
+		opened      time.Time       // The time the current file was created
+		records     int             // The number of records in the current file
+		files       []string        // The files written so far
+		record      [recordHeaderSize]byte
+	}
+	RecorderOption func(r *Recorder) error
+
+	// Record is a single raw PCIC message of a recording
+	Record struct {
+		Received time.Time // The time the message was received by the host
+		Message  []byte    // The raw message including the header and the trailer
+	}
+
+	// RecordingReader reads the records of one or more recording files
+	RecordingReader struct {
+		paths  []string
+		file   *os.File
+		reader *bufio.Reader
+		gzip   *gzip.Reader
+		header RecordingHeader
+		record [recordHeaderSize]byte
+	}
+
+	countingWriter struct {
+		w io.Writer
+		n int64
+	}
+)
+
+// NewRecorder creates the first recording file at the given path
+func NewRecorder(path string, options ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{path: path}
+	// Apply options
+	for _, opt := range options {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	if err := r.open(time.Now()); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// WithSource stores the host and the PCIC port the messages are received from
+func WithSource(host string, port uint16) RecorderOption {
+	return func(r *Recorder) error {
+		r.host = host
+		r.port = port
+		return nil
+	}
+}
+
+// WithDeviceConfig stores the JSON configuration of the device, e.g. the result of ovp8xx.Client.Get
+func WithDeviceConfig(config string) RecorderOption {
+	return func(r *Recorder) error {
+		if !json.Valid([]byte(config)) {
+			return errors.New("the device configuration is not valid JSON")
+		}
+		r.config = json.RawMessage(config)
+		return nil
+	}
+}
+
+// WithCompression compresses the records of each file with gzip
+func WithCompression() RecorderOption {
+	return func(r *Recorder) error {
+		r.compress = true
+		return nil
+	}
+}
+
+// WithRotation starts a new file once the file reaches maxSize bytes or
+// maxDuration elapsed, a zero value disables the limit. The files are named
+// after the path with a sequence number added, e.g. "capture-0001.pcic".
+//
+// The size is checked before each record is written.
+func WithRotation(maxSize int64, maxDuration time.Duration) RecorderOption {
+	return func(r *Recorder) error {
+		if maxSize < 0 || maxDuration < 0 {
+			return errors.New("the rotation limits must not be negative")
+		}
+		r.maxSize = maxSize
+		r.maxDuration = maxDuration
+		return nil
+	}
+}
+
+// RecordMessage writes the message with its receive time to the current file
+//
+// The record is flushed to the file, so the recording keeps all complete
+// records in case the process is killed before Close is called.
+func (r *Recorder) RecordMessage(received time.Time, message []byte) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return ErrRecorderClosed
+	}
+	if r.rotationDue(received) {
+		if err := r.closeFile(); err != nil {
+			return err
+		}
+		if err := r.open(received); err != nil {
+			return err
+		}
+	}
+	binary.LittleEndian.PutUint64(r.record[:8], uint64(received.UnixNano()))
+	binary.LittleEndian.PutUint32(r.record[8:], uint32(len(message)))
+	if _, err := r.writer.Write(r.record[:]); err != nil {
+		return err
+	}
+	if _, err := r.writer.Write(message); err != nil {
+		return err
+	}
+	r.records++
+	return r.flush()
+}
+
+// flush hands the buffered records to the file, compressed records are
+// flushed as well so they can be decompressed without the gzip trailer.
+func (r *Recorder) flush() error {
+	if err := r.writer.Flush(); err != nil {
+		return err
+	}
+	if r.gzip != nil {
+		return r.gzip.Flush()
+	}
+	return nil
+}
+
+// Files returns the paths of the files written so far
+func (r *Recorder) Files() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.files...)
+}
+
+// Close flushes the records and closes the current file
+func (r *Recorder) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	return r.closeFile()
+}
+
+func (r *Recorder) rotationDue(now time.Time) bool {
+	if r.records == 0 {
+		return false
+	}
+	return (r.maxSize > 0 && r.counter.n+int64(r.writer.Buffered()) >= r.maxSize) ||
+		(r.maxDuration > 0 && now.Sub(r.opened) >= r.maxDuration)
+}
+
+// filePath returns the path of the file with the given sequence number
+func (r *Recorder) filePath(sequence int) string {
+	if r.maxSize == 0 && r.maxDuration == 0 {
+		return r.path
+	}
+	ext := filepath.Ext(r.path)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(r.path, ext), sequence, ext)
+}
+
+func (r *Recorder) open(now time.Time) error {
+	sequence := len(r.files) + 1
+	path := r.filePath(sequence)
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	header := RecordingHeader{
+		Version:      recordingVersion,
+		Created:      now,
+		Host:         r.host,
+		Port:         r.port,
+		Compression:  CompressionNone,
+		Sequence:     sequence,
+		DeviceConfig: r.config,
+	}
+	if r.compress {
+		header.Compression = CompressionGzip
+	}
+	encoded, err := json.Marshal(header)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	prefix := make([]byte, 0, len(recordingMagic)+4+len(encoded))
+	prefix = append(prefix, recordingMagic...)
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(encoded)))
+	prefix = append(prefix, encoded...)
+	if _, err := file.Write(prefix); err != nil {
+		file.Close()
+		return err
+	}
+	r.file = file
+	r.counter = &countingWriter{w: file, n: int64(len(prefix))}
+	var w io.Writer = r.counter
+	r.gzip = nil
+	if r.compress {
+		r.gzip = gzip.NewWriter(r.counter)
+		w = r.gzip
+	}
+	r.writer = bufio.NewWriter(w)
+	r.opened = now
+	r.records = 0
+	r.files = append(r.files, path)
+	return nil
+}
+
+func (r *Recorder) closeFile() error {
+	err := r.writer.Flush()
+	if r.gzip != nil {
+		err = errors.Join(err, r.gzip.Close())
+	}
+	err = errors.Join(err, r.file.Close())
+	r.file = nil
+	return err
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// RecordingFiles returns the files of the recording at path in the order they were written.
+//
+// In case the file at path exists it is returned as is, otherwise the files
+// written WithRotation, e.g. "capture-0001.pcic", "capture-0002.pcic", are
+// returned ordered by their sequence number. The result can be passed to
+// OpenRecording or NewReplayer.
+func RecordingFiles(path string) ([]string, error) {
+	if _, err := os.Stat(path); err == nil {
+		return []string{path}, nil
+	}
+	dir, name := filepath.Split(path)
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext) + "-"
+	entries, err := os.ReadDir(filepath.Clean(dir + "."))
+	if err != nil {
+		return nil, err
+	}
+	type segment struct {
+		path     string
+		sequence int
+	}
+	segments := []segment{}
+	for _, entry := range entries {
+		candidate := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(candidate, prefix) || !strings.HasSuffix(candidate, ext) {
+			continue
+		}
+		digits := strings.TrimSuffix(strings.TrimPrefix(candidate, prefix), ext)
+		sequence, err := strconv.Atoi(digits)
+		if err != nil || sequence < 1 || len(digits) < 4 {
+			continue
+		}
+		segments = append(segments, segment{path: filepath.Join(dir, candidate), sequence: sequence})
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no recording found at %s", path)
+	}
+	slices.SortFunc(segments, func(a, b segment) int {
+		return a.sequence - b.sequence
+	})
+	files := make([]string, len(segments))
+	for i, s := range segments {
+		files[i] = s.path
+	}
+	return files, nil
+}
+
+// OpenRecording opens the recording files which are read in the given order
+func OpenRecording(paths ...string) (*RecordingReader, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no recording file given")
+	}
+	r := &RecordingReader{paths: paths}
+	if err := r.openNext(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Header returns the header of the file currently read
+func (r *RecordingReader) Header() RecordingHeader {
+	return r.header
+}
+
+// Next returns the next record, io.EOF is returned after the last record of the last file
+//
+// A record truncated at the end of a file, e.g. because the recording was
+// interrupted by a power loss, is treated as the end of the file.
+func (r *RecordingReader) Next() (Record, error) {
+	for {
+		if r.file == nil {
+			return Record{}, io.EOF
+		}
+		record, err := r.readRecord()
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			// The decompressor reports the truncated stream again
+			if err := r.closeFile(); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+				return Record{}, err
+			}
+			if len(r.paths) == 0 {
+				return Record{}, io.EOF
+			}
+			if err := r.openNext(); err != nil {
+				return Record{}, err
+			}
+			continue
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("unable to read the record: %w", err)
+		}
+		return record, nil
+	}
+}
+
+// readRecord reads the next record of the current file
+func (r *RecordingReader) readRecord() (Record, error) {
+	if _, err := io.ReadFull(r.reader, r.record[:]); err != nil {
+		return Record{}, err
+	}
+	length := binary.LittleEndian.Uint32(r.record[8:])
+	if length > maxRecordSize {
+		return Record{}, fmt.Errorf("the record size: %d exceeds the maximum: %d", length, maxRecordSize)
+	}
+	record := Record{
+		Received: time.Unix(0, int64(binary.LittleEndian.Uint64(r.record[:8]))),
+		Message:  make([]byte, length),
+	}
+	if _, err := io.ReadFull(r.reader, record.Message); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
+
+// NextFrame returns the next result message as Frame together with its receive time
+//
+// Error, notification and reply messages are skipped.
+func (r *RecordingReader) NextFrame() (Frame, time.Time, error) {
+	for {
+		record, err := r.Next()
+		if err != nil {
+			return Frame{}, time.Time{}, err
+		}
+		if record.Ticket() != string(resultTicket) {
+			continue
+		}
+		frame, err := record.Frame()
+		return frame, record.Received, err
+	}
+}
+
+// Close closes the file currently read
+func (r *RecordingReader) Close() error {
+	r.paths = nil
+	if r.file == nil {
+		return nil
+	}
+	return r.closeFile()
+}
+
+func (r *RecordingReader) openNext() error {
+	path := r.paths[0]
+	r.paths = r.paths[1:]
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(file)
+	header, err := recordingHeaderParser(reader)
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("unable to read the recording %s: %w", path, err)
+	}
+	r.file = file
+	r.header = header
+	r.reader = reader
+	r.gzip = nil
+	switch header.Compression {
+	case CompressionNone:
+	case CompressionGzip:
+		if r.gzip, err = gzip.NewReader(reader); err != nil {
+			r.closeFile()
+			return fmt.Errorf("unable to read the recording %s: %w", path, err)
+		}
+		r.reader = bufio.NewReader(r.gzip)
+	default:
+		r.closeFile()
+		return fmt.Errorf("unsupported compression of the recording %s: %s", path, header.Compression)
+	}
+	return nil
+}
+
+func (r *RecordingReader) closeFile() error {
+	var err error
+	if r.gzip != nil {
+		err = r.gzip.Close()
+	}
+	err = errors.Join(err, r.file.Close())
+	r.file = nil
+	return err
+}
+
+// recordingHeaderParser reads the magic and the header of a recording file
+func recordingHeaderParser(reader io.Reader) (RecordingHeader, error) {
+	prefix := make([]byte, len(recordingMagic)+4)
+	if _, err := io.ReadFull(reader, prefix); err != nil {
+		return RecordingHeader{}, err
+	}
+	if string(prefix[:len(recordingMagic)]) != recordingMagic {
+		return RecordingHeader{}, errors.New("the file is not a PCIC recording")
+	}
+	length := binary.LittleEndian.Uint32(prefix[len(recordingMagic):])
+	if length > recordingHeaderLimit {
+		return RecordingHeader{}, fmt.Errorf("the header size: %d exceeds the maximum: %d", length, recordingHeaderLimit)
+	}
+	encoded := make([]byte, length)
+	if _, err := io.ReadFull(reader, encoded); err != nil {
+		return RecordingHeader{}, err
+	}
+	header := RecordingHeader{}
+	if err := json.Unmarshal(encoded, &header); err != nil {
+		return RecordingHeader{}, err
+	}
+	if header.Version != recordingVersion {
+		return RecordingHeader{}, fmt.Errorf("unsupported recording version: %d", header.Version)
+	}
+	return header, nil
+}
+
+// Ticket returns the ticket of the message, e.g. "0000" for a result
+func (r Record) Ticket() string {
+	if len(r.Message) < ticketFieldLength {
+		return ""
+	}
+	return string(r.Message[:ticketFieldLength])
+}
+
+// Frame parses the result message of the Record
+func (r Record) Frame() (Frame, error) {
+	if len(r.Message) < dataOffset+delimiterFieldLength {
+		return Frame{}, errors.New("the recorded message is too short")
+	}
+	if r.Ticket() != string(resultTicket) {
+		return Frame{}, fmt.Errorf("the recorded message with the ticket %s is not a result", r.Ticket())
+	}
+	return asyncResultParser(r.Message[dataOffset:])
+}
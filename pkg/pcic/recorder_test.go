@@ -0,0 +1,246 @@
+package pcic_test
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordAll processes the messages with a PCICClient recording to the recorder
+func recordAll(t *testing.T, data []byte, recorder *pcic.Recorder) {
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+		pcic.WithRecorder(recorder),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	for {
+		err := p.ProcessIncomming(&PCICAsyncReceiver{})
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !assert.NoError(t, err, "No error expected while receiving data") {
+			return
+		}
+	}
+	assert.NoError(t, recorder.Close(), "We expect no error while closing the recorder")
+}
+
+func recordedInput(t *testing.T) []byte {
+	notification := "0010000500000:{}"
+	data := syntheticMessages(t, 3)
+	return append(data, fmt.Sprintf("0010L%09d\r\n%s\r\n", len(notification)+2, notification)...)
+}
+
+func TestRecordAndRead(t *testing.T) {
+	for _, options := range [][]pcic.RecorderOption{
+		{},
+		{pcic.WithCompression()},
+	} {
+		path := filepath.Join(t.TempDir(), "capture.pcic")
+		recorder, err := pcic.NewRecorder(path, append(options,
+			pcic.WithSource("192.168.0.69", 50010),
+			pcic.WithDeviceConfig(`{"device": {"info": {"name": "test"}}}`),
+		)...)
+		assert.NoError(t, err, "We expect no error while creating the recorder")
+		start := time.Now()
+		input := recordedInput(t)
+		recordAll(t, input, recorder)
+		assert.Equal(t, []string{path}, recorder.Files(), "We expect a single file without rotation")
+
+		reader, err := pcic.OpenRecording(path)
+		assert.NoError(t, err, "We expect no error while opening the recording")
+		header := reader.Header()
+		assert.Equal(t, "192.168.0.69", header.Host, "A host mismatch occurred")
+		assert.Equal(t, uint16(50010), header.Port, "A port mismatch occurred")
+		assert.JSONEq(t, `{"device": {"info": {"name": "test"}}}`, string(header.DeviceConfig))
+
+		raw := []byte{}
+		for {
+			record, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if !assert.NoError(t, err, "We expect no error while reading the records") {
+				break
+			}
+			assert.False(t, record.Received.Before(start), "We expect the receive time to be recorded")
+			raw = append(raw, record.Message...)
+		}
+		assert.Equal(t, input, raw, "We expect the raw messages to be recorded unchanged")
+		assert.NoError(t, reader.Close(), "We expect no error while closing the recording")
+	}
+}
+
+func TestRecordingFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.pcic")
+	recorder, err := pcic.NewRecorder(path, pcic.WithCompression())
+	assert.NoError(t, err, "We expect no error while creating the recorder")
+	recordAll(t, recordedInput(t), recorder)
+
+	reader, err := pcic.OpenRecording(path)
+	assert.NoError(t, err, "We expect no error while opening the recording")
+	defer reader.Close()
+	frames := 0
+	for {
+		frame, _, err := reader.NextFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !assert.NoError(t, err, "We expect no error while reading the frames") {
+			break
+		}
+		assert.Equal(t, 3, len(frame.Chunks), "A chunk count mismatch occurred")
+		frames++
+	}
+	assert.Equal(t, 3, frames, "We expect the notification to be skipped")
+}
+
+func TestRecorderRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.pcic")
+	// Each of the synthetic messages exceeds the size limit
+	recorder, err := pcic.NewRecorder(path, pcic.WithRotation(1024, 0))
+	assert.NoError(t, err, "We expect no error while creating the recorder")
+	recordAll(t, syntheticMessages(t, 3), recorder)
+	files := recorder.Files()
+	assert.Equal(t, []string{
+		filepath.Join(filepath.Dir(path), "capture-0001.pcic"),
+		filepath.Join(filepath.Dir(path), "capture-0002.pcic"),
+		filepath.Join(filepath.Dir(path), "capture-0003.pcic"),
+	}, files, "We expect a file per message")
+
+	reader, err := pcic.OpenRecording(files...)
+	assert.NoError(t, err, "We expect no error while opening the recording")
+	defer reader.Close()
+	frames := 0
+	for {
+		_, _, err := reader.NextFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if !assert.NoError(t, err, "We expect no error while reading the frames") {
+			break
+		}
+		frames++
+	}
+	assert.Equal(t, 3, frames, "We expect the frames of all files")
+	assert.Equal(t, 3, reader.Header().Sequence, "We expect the header of the last file")
+
+	discovered, err := pcic.RecordingFiles(path)
+	assert.NoError(t, err, "We expect no error while listing the rotated files")
+	assert.Equal(t, files, discovered, "We expect the rotated files in the order they were written")
+
+	_, err = pcic.NewRecorder(path, pcic.WithRotation(-1, 0))
+	assert.Error(t, err, "We expect an error due to a negative limit")
+}
+
+func TestRecordingFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"capture-0010.pcic", "capture-0002.pcic", "capture-10000.pcic", "capture-0001.pcic",
+		"capture-last.pcic", "capture-0003.bin", "other-0001.pcic",
+	} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0o644))
+	}
+	files, err := pcic.RecordingFiles(filepath.Join(dir, "capture.pcic"))
+	assert.NoError(t, err, "We expect no error while listing the rotated files")
+	assert.Equal(t, []string{
+		filepath.Join(dir, "capture-0001.pcic"),
+		filepath.Join(dir, "capture-0002.pcic"),
+		filepath.Join(dir, "capture-0010.pcic"),
+		filepath.Join(dir, "capture-10000.pcic"),
+	}, files, "We expect the files ordered by their sequence number")
+
+	files, err = pcic.RecordingFiles(filepath.Join(dir, "other-0001.pcic"))
+	assert.NoError(t, err, "We expect no error for an existing file")
+	assert.Equal(t, []string{filepath.Join(dir, "other-0001.pcic")}, files, "We expect the file as is")
+
+	_, err = pcic.RecordingFiles(filepath.Join(dir, "missing.pcic"))
+	assert.Error(t, err, "We expect an error in case no file matches")
+}
+
+func TestRecorderClosed(t *testing.T) {
+	recorder, err := pcic.NewRecorder(filepath.Join(t.TempDir(), "capture.pcic"))
+	assert.NoError(t, err, "We expect no error while creating the recorder")
+	assert.NoError(t, recorder.Close(), "We expect no error while closing the recorder")
+	assert.ErrorIs(t,
+		recorder.RecordMessage(time.Now(), []byte("message")),
+		pcic.ErrRecorderClosed,
+		"We expect an error after the recorder is closed",
+	)
+	_, err = pcic.NewRecorder(filepath.Join(t.TempDir(), "capture.pcic"), pcic.WithDeviceConfig("{"))
+	assert.Error(t, err, "We expect an error due to an invalid device configuration")
+}
+
+func TestOpenMalformedRecording(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "capture.pcic")
+	assert.NoError(t, os.WriteFile(path, []byte("PCICREC0\x00\x00\x00\x00"), 0o644))
+	_, err := pcic.OpenRecording(path)
+	assert.Error(t, err, "We expect an error due to a wrong magic")
+	_, err = pcic.OpenRecording(filepath.Join(t.TempDir(), "missing.pcic"))
+	assert.Error(t, err, "We expect an error due to a missing file")
+	_, err = pcic.OpenRecording()
+	assert.Error(t, err, "We expect an error without a file")
+}
+
+// readRecords reads the messages of the recording until io.EOF
+func readRecords(t *testing.T, paths ...string) [][]byte {
+	reader, err := pcic.OpenRecording(paths...)
+	assert.NoError(t, err, "We expect no error while opening the recording")
+	defer reader.Close()
+	messages := [][]byte{}
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return messages
+		}
+		if !assert.NoError(t, err, "We expect no error while reading the records") {
+			return messages
+		}
+		messages = append(messages, record.Message)
+	}
+}
+
+func TestInterruptedRecording(t *testing.T) {
+	messages := make([][]byte, 3)
+	for i := range messages {
+		messages[i] = bytes.Repeat([]byte(fmt.Sprintf("message %d;", i)), 100)
+	}
+	for _, options := range [][]pcic.RecorderOption{
+		{},
+		{pcic.WithCompression()},
+	} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "capture.pcic")
+		recorder, err := pcic.NewRecorder(path, options...)
+		assert.NoError(t, err, "We expect no error while creating the recorder")
+		for _, message := range messages {
+			assert.NoError(t, recorder.RecordMessage(time.Now(), message), "We expect no error while recording")
+		}
+		// Simulate a killed process, the recorder is not closed
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err, "We expect no error while reading the recording")
+		interrupted := filepath.Join(dir, "interrupted.pcic")
+		assert.NoError(t, os.WriteFile(interrupted, data, 0o644))
+		assert.Equal(t, messages, readRecords(t, interrupted), "We expect all records to be flushed")
+
+		// Cut the last record
+		truncated := filepath.Join(dir, "truncated.pcic")
+		assert.NoError(t, os.WriteFile(truncated, data[:len(data)-10], 0o644))
+		assert.Equal(t, messages[:2], readRecords(t, truncated), "We expect the complete records only")
+		assert.Equal(t,
+			append(messages[:2:2], messages...),
+			readRecords(t, truncated, interrupted),
+			"We expect the next file to be read after a truncated one",
+		)
+		assert.NoError(t, recorder.Close(), "We expect no error while closing the recorder")
+	}
+}
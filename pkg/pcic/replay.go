@@ -0,0 +1,327 @@
+package pcic
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// ReplayMode selects how the replayed messages are paced
+type ReplayMode int
+
+const (
+	// REPLAY_ORIGINAL_TIMING reproduces the time between the recorded messages
+	REPLAY_ORIGINAL_TIMING ReplayMode = iota
+	// REPLAY_AS_FAST_AS_POSSIBLE sends the messages without any delay
+	REPLAY_AS_FAST_AS_POSSIBLE
+	// REPLAY_FIXED_RATE sends the result messages with a fixed frame rate
+	REPLAY_FIXED_RATE
+)
+
+type (
+	// Replayer serves recorded PCIC messages over TCP the way a device does.
+	//
+	// Each connection gets its own replay of the recording. Commands sent by
+	// the client are acknowledged with "*" but otherwise ignored, this way
+	// consumers calling e.g. SetResultOutput work unmodified. Reply messages
+	// contained in the recording are not replayed.
+	Replayer struct {
+		paths    []string      // The recording files in the order they are replayed
+		mode     ReplayMode    // The pacing of the messages
+		interval time.Duration // The time between two results in REPLAY_FIXED_RATE mode
+		loop     bool          // Start over once the end of the recording is reached
+		first    int           // The index of the first result replayed
+		last     int           // The index of the last result replayed, negative for the end of the recording
+		logger   *slog.Logger
+	}
+	ReplayerOption func(r *Replayer) error
+)
+
+// NewReplayer creates a Replayer for the recording files which are replayed in the given order
+func NewReplayer(paths []string, options ...ReplayerOption) (*Replayer, error) {
+	r := &Replayer{
+		paths:  paths,
+		mode:   REPLAY_ORIGINAL_TIMING,
+		last:   -1,
+		logger: slog.Default(),
+	}
+	// Apply options
+	for _, opt := range options {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+	// Fail early in case the recording can not be read
+	reader, err := OpenRecording(paths...)
+	if err != nil {
+		return nil, err
+	}
+	return r, reader.Close()
+}
+
+// WithOriginalTiming replays the messages with the recorded time in between, this is the default
+func WithOriginalTiming() ReplayerOption {
+	return func(r *Replayer) error {
+		r.mode = REPLAY_ORIGINAL_TIMING
+		return nil
+	}
+}
+
+// WithoutPacing replays the messages as fast as possible
+func WithoutPacing() ReplayerOption {
+	return func(r *Replayer) error {
+		r.mode = REPLAY_AS_FAST_AS_POSSIBLE
+		return nil
+	}
+}
+
+// WithFixedRate replays the result messages with the given frames per second
+func WithFixedRate(fps float64) ReplayerOption {
+	return func(r *Replayer) error {
+		if fps <= 0 {
+			return fmt.Errorf("the frame rate has to be positive: %v", fps)
+		}
+		r.mode = REPLAY_FIXED_RATE
+		r.interval = time.Duration(float64(time.Second) / fps)
+		return nil
+	}
+}
+
+// WithLoop starts the replay over once the end of the recording or the frame range is reached
+func WithLoop() ReplayerOption {
+	return func(r *Replayer) error {
+		r.loop = true
+		return nil
+	}
+}
+
+// WithFrameRange replays the result messages from the index first to last inclusive.
+//
+// The indices count the result messages of the recording starting at zero, a
+// negative last replays up to the end of the recording. Error and notification
+// messages are replayed in case they are received within the range.
+func WithFrameRange(first, last int) ReplayerOption {
+	return func(r *Replayer) error {
+		if first < 0 || (last >= 0 && last < first) {
+			return fmt.Errorf("invalid frame range: %d-%d", first, last)
+		}
+		r.first = first
+		r.last = last
+		return nil
+	}
+}
+
+// WithReplayLogger sets the logger used to report the connections, by default slog.Default() is used
+func WithReplayLogger(logger *slog.Logger) ReplayerOption {
+	return func(r *Replayer) error {
+		if logger == nil {
+			return errors.New("no logger provided")
+		}
+		r.logger = logger
+		return nil
+	}
+}
+
+// ListenAndServe listens on the TCP address and serves the recording until the context is canceled
+func (r *Replayer) ListenAndServe(ctx context.Context, address string) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	return r.Serve(ctx, listener)
+}
+
+// Serve accepts the connections of the listener and replays the recording on
+// each of them. The listener is closed when Serve returns. The returned error
+// is the error of the context in case the context was canceled.
+func (r *Replayer) Serve(ctx context.Context, listener net.Listener) error {
+	stop := context.AfterFunc(ctx, func() {
+		// Unblock the pending accept
+		listener.Close()
+	})
+	defer stop()
+	defer listener.Close()
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.logger.Info("Replay started", slog.String("remote", conn.RemoteAddr().String()))
+			err := r.ServeConn(ctx, conn)
+			r.logger.Info("Replay finished",
+				slog.String("remote", conn.RemoteAddr().String()),
+				slog.Any("error", err),
+			)
+		}()
+	}
+}
+
+// ServeConn replays the recording on a single connection.
+//
+// The connection is closed when the replay is finished, when the context is
+// canceled or when the client closes the connection. In the first case nil is
+// returned.
+func (r *Replayer) ServeConn(ctx context.Context, conn net.Conn) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(ctx, func() {
+		// Unblock the pending read and write
+		conn.Close()
+	})
+	defer stop()
+	defer conn.Close()
+	mutex := &sync.Mutex{}
+	acknowledged := make(chan error, 1)
+	go func() {
+		err := acknowledgeCommands(conn, mutex)
+		// The client is gone, stop the replay
+		cancel()
+		acknowledged <- err
+	}()
+	err := r.replay(ctx, conn, mutex)
+	conn.Close()
+	<-acknowledged
+	return err
+}
+
+// replay writes the recorded messages to the writer until the end of the recording
+func (r *Replayer) replay(ctx context.Context, writer io.Writer, mutex *sync.Mutex) error {
+	for {
+		sent, err := r.replayOnce(ctx, writer, mutex)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		if !r.loop || sent == 0 {
+			return nil
+		}
+	}
+}
+
+// replayOnce replays the frame range once and returns the number of messages sent
+func (r *Replayer) replayOnce(ctx context.Context, writer io.Writer, mutex *sync.Mutex) (int, error) {
+	reader, err := OpenRecording(r.paths...)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	var start, recorded time.Time
+	sent := 0
+	results := 0
+	for {
+		record, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+		isResult := record.Ticket() == string(resultTicket)
+		if !isResult && record.Ticket() != string(errorTicket) && record.Ticket() != string(notificationTicket) {
+			// The replies belong to the commands of the recording client
+			continue
+		}
+		if r.last >= 0 && results > r.last {
+			return sent, nil
+		}
+		index := results
+		if isResult {
+			results++
+		}
+		if index < r.first {
+			continue
+		}
+		if sent == 0 {
+			start, recorded = time.Now(), record.Received
+		}
+		if err := r.wait(ctx, start, recorded, record, index-r.first); err != nil {
+			return sent, err
+		}
+		mutex.Lock()
+		_, err = writer.Write(record.Message)
+		mutex.Unlock()
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+}
+
+// wait delays the record according to the ReplayMode, the replay started at
+// start with the record received at recorded. The frame is the index of the
+// result within the frame range.
+func (r *Replayer) wait(ctx context.Context, start, recorded time.Time, record Record, frame int) error {
+	var due time.Time
+	switch r.mode {
+	case REPLAY_ORIGINAL_TIMING:
+		due = start.Add(record.Received.Sub(recorded))
+	case REPLAY_FIXED_RATE:
+		due = start.Add(time.Duration(frame) * r.interval)
+	default:
+		return ctx.Err()
+	}
+	timer := time.NewTimer(time.Until(due))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// acknowledgeCommands reads the commands of the client and answers each with "*"
+func acknowledgeCommands(conn net.Conn, mutex *sync.Mutex) error {
+	reader := bufio.NewReader(conn)
+	header := make([]byte, headerSize)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return err
+		}
+		ticket := header[:ticketFieldLength]
+		if !bytes.Equal(ticket, header[secondTicketOffset:dataOffset]) {
+			return fmt.Errorf("mismatch in the tickets %s != %s ",
+				string(ticket),
+				string(header[secondTicketOffset:dataOffset]),
+			)
+		}
+		length, err := lengthParser(header[lengthOffset:secondTicketOffset])
+		if err != nil {
+			return err
+		}
+		if length < minimumContentLength {
+			return errors.New("the length information is too short")
+		}
+		if _, err := io.CopyN(io.Discard, reader, int64(length-ticketFieldLength)); err != nil {
+			return err
+		}
+		mutex.Lock()
+		_, err = fmt.Fprintf(conn,
+			"%sL%09d\r\n%s*\r\n",
+			ticket,
+			ticketFieldLength+1+delimiterFieldLength,
+			ticket,
+		)
+		mutex.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package pcic_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// PCICCountingReceiver counts the received messages and cancels once enough results arrived
+type PCICCountingReceiver struct {
+	mutex         sync.Mutex
+	results       int
+	received      []time.Time // The arrival time of each result
+	notifications int
+	limit         int
+	cancel        context.CancelFunc
+}
+
+func (r *PCICCountingReceiver) Result(frame pcic.Frame) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.results++
+	r.received = append(r.received, time.Now())
+	if r.limit > 0 && r.results >= r.limit {
+		r.cancel()
+	}
+}
+
+func (r *PCICCountingReceiver) Error(msg pcic.ErrorMessage) {}
+
+func (r *PCICCountingReceiver) Notification(msg pcic.NotificationMessage) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.notifications++
+}
+
+// testRecording records the synthetic messages followed by a notification
+func testRecording(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "capture.pcic")
+	recorder, err := pcic.NewRecorder(path)
+	assert.NoError(t, err, "We expect no error while creating the recorder")
+	recordAll(t, recordedInput(t), recorder)
+	return path
+}
+
+// timedRecording records count results which are received gap apart
+func timedRecording(t *testing.T, count int, gap time.Duration) string {
+	path := filepath.Join(t.TempDir(), "timed.pcic")
+	recorder, err := pcic.NewRecorder(path)
+	assert.NoError(t, err, "We expect no error while creating the recorder")
+	message := syntheticMessages(t, 1)
+	received := time.Unix(1700000000, 0)
+	for i := 0; i < count; i++ {
+		assert.NoError(t,
+			recorder.RecordMessage(received.Add(time.Duration(i)*gap), message),
+			"We expect no error while recording",
+		)
+	}
+	assert.NoError(t, recorder.Close(), "We expect no error while closing the recorder")
+	return path
+}
+
+// assertSpacing checks that consecutive results arrived at least minimum apart
+func assertSpacing(t *testing.T, received []time.Time, minimum time.Duration) {
+	for i := 1; i < len(received); i++ {
+		assert.GreaterOrEqual(t,
+			received[i].Sub(received[i-1]),
+			minimum,
+			"We expect the result %d to be paced", i,
+		)
+	}
+}
+
+// replay serves the recording on a local port and consumes it with an
+// unmodified TCP client until the replay ends or limit results are received.
+func replay(t *testing.T, limit int, options ...pcic.ReplayerOption) (*PCICCountingReceiver, error) {
+	return replayRecording(t, testRecording(t), limit, options...)
+}
+
+// replayRecording replays the recording at path, see replay
+func replayRecording(t *testing.T, path string, limit int, options ...pcic.ReplayerOption) (*PCICCountingReceiver, error) {
+	replayer, err := pcic.NewReplayer([]string{path}, options...)
+	assert.NoError(t, err, "We expect no error while creating the replayer")
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err, "We expect no error while listening")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- replayer.Serve(ctx, listener)
+	}()
+	port := listener.Addr().(*net.TCPAddr).Port
+	p, err := pcic.NewPCICClient(pcic.WithTCPClient("127.0.0.1", uint16(port)))
+	assert.NoError(t, err, "We expect no error while connecting to the replayer")
+	clientCtx, clientCancel := context.WithCancel(ctx)
+	defer clientCancel()
+	handler := &PCICCountingReceiver{limit: limit, cancel: clientCancel}
+	err = p.Run(clientCtx, handler)
+	cancel()
+	assert.ErrorIs(t, <-served, context.Canceled, "We expect Serve to end with the context")
+	return handler, err
+}
+
+func TestReplayAsFastAsPossible(t *testing.T) {
+	handler, err := replay(t, 0, pcic.WithoutPacing())
+	assert.ErrorIs(t, err, pcic.ErrConnectionLost, "We expect the connection to be closed after the replay")
+	assert.Equal(t, 3, handler.results, "A result count mismatch occurred")
+	assert.Equal(t, 1, handler.notifications, "A notification count mismatch occurred")
+}
+
+func TestReplayFrameRange(t *testing.T) {
+	handler, err := replay(t, 0, pcic.WithoutPacing(), pcic.WithFrameRange(1, 1))
+	assert.ErrorIs(t, err, pcic.ErrConnectionLost, "We expect the connection to be closed after the replay")
+	assert.Equal(t, 1, handler.results, "A result count mismatch occurred")
+	assert.Equal(t, 0, handler.notifications, "We expect the notification to be out of range")
+
+	handler, _ = replay(t, 0, pcic.WithoutPacing(), pcic.WithFrameRange(2, -1))
+	assert.Equal(t, 1, handler.results, "A result count mismatch occurred")
+	assert.Equal(t, 1, handler.notifications, "We expect the notification to be in range")
+}
+
+func TestReplayLoop(t *testing.T) {
+	handler, err := replay(t, 10, pcic.WithoutPacing(), pcic.WithLoop())
+	assert.ErrorIs(t, err, context.Canceled, "We expect the replay to continue until canceled")
+	assert.GreaterOrEqual(t, handler.results, 10, "A result count mismatch occurred")
+}
+
+func TestReplayFixedRate(t *testing.T) {
+	// The recorded results are received back-to-back
+	handler, _ := replay(t, 0, pcic.WithFixedRate(20))
+	assert.Equal(t, 3, handler.results, "A result count mismatch occurred")
+	assertSpacing(t, handler.received, 30*time.Millisecond)
+	assert.GreaterOrEqual(t,
+		handler.received[2].Sub(handler.received[0]),
+		80*time.Millisecond,
+		"We expect the results to be sent at the fixed rate",
+	)
+}
+
+func TestReplayOriginalTiming(t *testing.T) {
+	gap := 50 * time.Millisecond
+	handler, err := replayRecording(t, timedRecording(t, 4, gap), 0)
+	assert.ErrorIs(t, err, pcic.ErrConnectionLost, "We expect the connection to be closed after the replay")
+	assert.Equal(t, 4, handler.results, "A result count mismatch occurred")
+	assertSpacing(t, handler.received, gap*6/10)
+	assert.GreaterOrEqual(t,
+		handler.received[3].Sub(handler.received[0]),
+		gap*3-gap/2,
+		"We expect the recorded gaps to be kept",
+	)
+
+	handler, _ = replayRecording(t, timedRecording(t, 4, gap), 0, pcic.WithoutPacing())
+	assert.Less(t,
+		handler.received[3].Sub(handler.received[0]),
+		gap,
+		"We expect the recorded gaps to be ignored without pacing",
+	)
+}
+
+func TestReplayAcknowledgesCommands(t *testing.T) {
+	replayer, err := pcic.NewReplayer([]string{testRecording(t)}, pcic.WithFixedRate(100), pcic.WithLoop())
+	assert.NoError(t, err, "We expect no error while creating the replayer")
+	host, device := net.Pipe()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- replayer.ServeConn(ctx, device)
+	}()
+	p, err := pcic.NewPCICClient(pcic.WithConn(host))
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	go p.Run(ctx, &PCICCountingReceiver{})
+	assert.NoError(t,
+		p.SetResultOutput(ctx, true),
+		"We expect the replayer to acknowledge the command",
+	)
+	p.Close()
+	assert.Error(t, <-served, "We expect an error once the client is gone")
+}
+
+func TestReplayerOptions(t *testing.T) {
+	path := testRecording(t)
+	_, err := pcic.NewReplayer([]string{path}, pcic.WithFixedRate(0))
+	assert.Error(t, err, "We expect an error for a frame rate of zero")
+	_, err = pcic.NewReplayer([]string{path}, pcic.WithFrameRange(3, 2))
+	assert.Error(t, err, "We expect an error for an invalid frame range")
+	_, err = pcic.NewReplayer([]string{filepath.Join(t.TempDir(), "missing.pcic")})
+	assert.Error(t, err, "We expect an error for a missing recording")
+}
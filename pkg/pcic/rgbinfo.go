@@ -0,0 +1,89 @@
+package pcic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"time"
+)
+
+// The minimum supported version of the RGB_INFO chunk
+const minRGBInfoVersion = 1
+
+// RGBInfo is the calibration and acquisition state of a 2D frame
+type RGBInfo struct {
+	Version                     uint32    // The version of the RGB_INFO layout
+	FrameCounter                uint32    // The frame counter of the 2D head
+	TimeStamp                   time.Time // The time the image was acquired
+	ExposureTime                float32   // The exposure time in seconds
+	ExtrinsicOpticToUser        Extrinsic // The transformation from the optical to the user coordinate system
+	IntrinsicCalibration        Intrinsic // The intrinsic camera model
+	InverseIntrinsicCalibration Intrinsic // The inverse intrinsic camera model
+}
+
+// rgbInfoLayoutV1 is the binary layout of the version 1 RGB_INFO chunk
+type rgbInfoLayoutV1 struct {
+	Version                     uint32
+	FrameCounter                uint32
+	TimestampNsec               uint64
+	ExposureTime                float32
+	ExtrinsicOpticToUser        Extrinsic
+	IntrinsicCalibration        Intrinsic
+	InverseIntrinsicCalibration Intrinsic
+}
+
+// RGBInfoFromChunk decodes the RGB_INFO chunk
+func RGBInfoFromChunk(c *Chunk) (*RGBInfo, error) {
+	if c.chunkType != RGB_INFO {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, RGB_INFO)
+	}
+	info := &RGBInfo{}
+	if err := info.UnmarshalBinary(c.data); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// UnmarshalBinary decodes the data section of a RGB_INFO chunk
+func (info *RGBInfo) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("the %s data is too short: %d", RGB_INFO, len(data))
+	}
+	version := binary.LittleEndian.Uint32(data)
+	if version < minRGBInfoVersion {
+		return fmt.Errorf(
+			"unsupported %s version: %d minimum supported version: %d",
+			RGB_INFO,
+			version,
+			minRGBInfoVersion,
+		)
+	}
+	v1 := rgbInfoLayoutV1{}
+	if err := decodeVersioned(data, &v1, version); err != nil {
+		return err
+	}
+	*info = RGBInfo{
+		Version:                     v1.Version,
+		FrameCounter:                v1.FrameCounter,
+		TimeStamp:                   time.Unix(0, int64(v1.TimestampNsec)),
+		ExposureTime:                v1.ExposureTime,
+		ExtrinsicOpticToUser:        v1.ExtrinsicOpticToUser,
+		IntrinsicCalibration:        v1.IntrinsicCalibration,
+		InverseIntrinsicCalibration: v1.InverseIntrinsicCalibration,
+	}
+	return nil
+}
+
+// JPEGFromChunk decodes the JPEG_IMAGE chunk of a 2D head
+func JPEGFromChunk(c *Chunk) (image.Image, error) {
+	if c.chunkType != JPEG_IMAGE {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, JPEG_IMAGE)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(c.data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the %s chunk: %w", JPEG_IMAGE, err)
+	}
+	return img, nil
+}
@@ -0,0 +1,88 @@
+package pcic_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// rgbInfoV1 mirrors the binary layout of the version 1 RGB_INFO chunk
+type rgbInfoV1 struct {
+	Version                    uint32
+	FrameCounter               uint32
+	TimestampNsec              uint64
+	ExposureTime               float32
+	Extrinsic                  [6]float32
+	IntrinsicModelID           uint32
+	IntrinsicParameters        [32]float32
+	InverseIntrinsicModelID    uint32
+	InverseIntrinsicParameters [32]float32
+}
+
+func bytesChunk(chunkType pcic.ChunkType, data []byte) *pcic.Chunk {
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(chunkType),
+		pcic.WithDimension(len(data), 1, pcic.FORMAT_8U),
+	)
+	copy(chunk.Bytes(), data)
+	return chunk
+}
+
+func TestRGBInfoFromChunk(t *testing.T) {
+	raw := rgbInfoV1{
+		Version:          1,
+		FrameCounter:     7,
+		TimestampNsec:    1700000000000000042,
+		ExposureTime:     0.01,
+		Extrinsic:        [6]float32{0.1, 0, -0.2, 0, 0, 1.57},
+		IntrinsicModelID: 0,
+	}
+	raw.IntrinsicParameters[0] = 640.5
+	buffer := bytes.Buffer{}
+	assert.NoError(t, binary.Write(&buffer, binary.LittleEndian, raw), "We expect no error while encoding")
+
+	info, err := pcic.RGBInfoFromChunk(bytesChunk(pcic.RGB_INFO, buffer.Bytes()))
+	assert.NoError(t, err, "We expect no error while decoding the RGB_INFO chunk")
+	assert.Equal(t, uint32(7), info.FrameCounter, "A frame counter mismatch occurred")
+	assert.Equal(t, time.Unix(0, 1700000000000000042), info.TimeStamp, "A time stamp mismatch occurred")
+	assert.Equal(t, float32(0.01), info.ExposureTime, "An exposure time mismatch occurred")
+	assert.Equal(t, float32(1.57), info.ExtrinsicOpticToUser.RotZ, "An extrinsic mismatch occurred")
+	assert.Equal(t, float32(640.5), info.IntrinsicCalibration.Parameters[0])
+
+	_, err = pcic.RGBInfoFromChunk(bytesChunk(pcic.RGB_INFO, buffer.Bytes()[:100]))
+	assert.Error(t, err, "We expect an error due to truncated data")
+	raw.Version = 0
+	buffer.Reset()
+	assert.NoError(t, binary.Write(&buffer, binary.LittleEndian, raw), "We expect no error while encoding")
+	_, err = pcic.RGBInfoFromChunk(bytesChunk(pcic.RGB_INFO, buffer.Bytes()))
+	assert.Error(t, err, "We expect an error due to an unsupported version")
+	_, err = pcic.RGBInfoFromChunk(bytesChunk(pcic.TOF_INFO, buffer.Bytes()))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+}
+
+func TestJPEGFromChunk(t *testing.T) {
+	src := image.NewGray(image.Rect(0, 0, 16, 8))
+	for i := range src.Pix {
+		src.Pix[i] = 0x80
+	}
+	buffer := bytes.Buffer{}
+	assert.NoError(t, jpeg.Encode(&buffer, src, nil), "We expect no error while encoding")
+
+	img, err := pcic.JPEGFromChunk(bytesChunk(pcic.JPEG_IMAGE, buffer.Bytes()))
+	assert.NoError(t, err, "We expect no error while decoding the JPEG chunk")
+	assert.Equal(t, image.Rect(0, 0, 16, 8), img.Bounds(), "A bounds mismatch occurred")
+	gray := color.GrayModel.Convert(img.At(3, 3)).(color.Gray)
+	assert.InDelta(t, 0x80, gray.Y, 2, "A pixel mismatch occurred")
+
+	_, err = pcic.JPEGFromChunk(bytesChunk(pcic.JPEG_IMAGE, []byte("no jpeg")))
+	assert.Error(t, err, "We expect an error due to invalid JPEG data")
+	_, err = pcic.JPEGFromChunk(bytesChunk(pcic.RGB_INFO, buffer.Bytes()))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+}
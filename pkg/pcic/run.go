@@ -0,0 +1,104 @@
+package pcic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
+var (
+	// ErrClientClosed is returned when the PCICClient was closed while in use
+	ErrClientClosed = errors.New("the PCIC client is closed")
+	// ErrConnectionLost is returned when the device closed the connection
+	ErrConnectionLost = errors.New("the connection to the device was lost")
+	// ErrReadTimeout is returned when no message was received within the read timeout
+	ErrReadTimeout = errors.New("no message received within the read timeout")
+)
+
+// Run processes the incoming messages until the context is canceled or an error occurs.
+//
+// The underlying connection is closed when Run returns. The returned error is
+// never nil, it is the error of the context in case the context was canceled,
+// ErrClientClosed when Close was called, ErrConnectionLost when the device
+// closed the connection and ErrReadTimeout when no message was received within
+// the configured read timeout. Any other error is caused by malformed data.
+//
+// Canceling the context or calling Close interrupts the pending read by
+// closing the net.Conn. A client created WithBufioReaderWriter has no
+// connection to close, in this case Run returns after the pending read
+// completed, i.e. once the next message arrived or the reader failed.
+func (p *PCICClient) Run(ctx context.Context, handler MessageHandler) error {
+	stop := context.AfterFunc(ctx, func() {
+		// Unblock the pending read
+		p.Close()
+	})
+	defer stop()
+	defer p.Close()
+	for {
+		if err := p.stopped(ctx); err != nil {
+			return err
+		}
+		if err := p.setReadDeadline(); err != nil {
+			return p.terminalError(ctx, err)
+		}
+		if err := p.ProcessIncomming(handler); err != nil {
+			return p.terminalError(ctx, err)
+		}
+	}
+}
+
+// Close closes the underlying connection and fails all the commands waiting for a reply.
+//
+// It is safe to call Close multiple times and from multiple go routines.
+func (p *PCICClient) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		close(p.done)
+		if p.conn != nil {
+			err = p.conn.Close()
+		}
+	})
+	return err
+}
+
+// stopped returns the terminal error in case the context was canceled or Close was called
+func (p *PCICClient) stopped(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+	case <-p.done:
+	default:
+		return nil
+	}
+	return p.terminalError(ctx, nil)
+}
+
+// setReadDeadline sets the deadline for the next message if a read timeout is configured
+func (p *PCICClient) setReadDeadline() error {
+	if p.conn == nil || p.readTimeout <= 0 {
+		return nil
+	}
+	return p.conn.SetReadDeadline(time.Now().Add(p.readTimeout))
+}
+
+// terminalError maps the error which ended the receive loop to the documented errors
+func (p *PCICClient) terminalError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	select {
+	case <-p.done:
+		return ErrClientClosed
+	default:
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("%w: %s", ErrReadTimeout, p.readTimeout)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package pcic_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func newConnClient(t *testing.T, options ...pcic.PCICClientOption) (*pcic.PCICClient, net.Conn) {
+	host, device := net.Pipe()
+	p, err := pcic.NewPCICClient(append([]pcic.PCICClientOption{pcic.WithConn(host)}, options...)...)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	t.Cleanup(func() {
+		host.Close()
+		device.Close()
+	})
+	return p, device
+}
+
+func runAsync(ctx context.Context, p *pcic.PCICClient, handler pcic.MessageHandler) <-chan error {
+	result := make(chan error, 1)
+	go func() {
+		result <- p.Run(ctx, handler)
+	}()
+	return result
+}
+
+func waitForResult(t *testing.T, result <-chan error) error {
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return in time")
+	}
+	return nil
+}
+
+func TestRunCancel(t *testing.T) {
+	p, device := newConnClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	handler := &PCICAsyncReceiver{}
+	result := runAsync(ctx, p, handler)
+	assert.NoError(t, writeMessage(device, "0010", "000500000:{}"))
+	cancel()
+	err := waitForResult(t, result)
+	assert.ErrorIs(t, err, context.Canceled, "We expect the context error")
+	assert.Equal(t, 500000, handler.notificationMsg.ID, "We expect the notification to be processed")
+	_, err = device.Write([]byte("0000"))
+	assert.Error(t, err, "We expect the connection to be closed")
+}
+
+func TestRunCancelWithoutConn(t *testing.T) {
+	reader, writer := io.Pipe()
+	defer writer.Close()
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(reader), nil)),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	ctx, cancel := context.WithCancel(context.Background())
+	result := runAsync(ctx, p, &PCICAsyncReceiver{})
+	// The write returns once the message was read, give Run the time to block in the next read
+	assert.NoError(t, writeMessage(writer, "0000", "starstop"))
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-result:
+		t.Fatal("We expect the pending read not to be interrupted without a net.Conn")
+	case <-time.After(20 * time.Millisecond):
+	}
+	assert.NoError(t, writeMessage(writer, "0000", "starstop"))
+	assert.ErrorIs(t, waitForResult(t, result), context.Canceled, "We expect the context error after the read")
+}
+
+func TestRunReadTimeout(t *testing.T) {
+	p, _ := newConnClient(t, pcic.WithReadTimeout(20*time.Millisecond))
+	err := waitForResult(t, runAsync(context.Background(), p, &PCICAsyncReceiver{}))
+	assert.ErrorIs(t, err, pcic.ErrReadTimeout, "We expect a read timeout")
+}
+
+func TestRunConnectionLost(t *testing.T) {
+	p, device := newConnClient(t)
+	result := runAsync(context.Background(), p, &PCICAsyncReceiver{})
+	device.Close()
+	err := waitForResult(t, result)
+	assert.ErrorIs(t, err, pcic.ErrConnectionLost, "We expect the connection to be lost")
+}
+
+func TestRunClose(t *testing.T) {
+	p, device := newConnClient(t)
+	result := runAsync(context.Background(), p, &PCICAsyncReceiver{})
+	reply := make(chan error, 1)
+	go func() {
+		_, err := p.Send(context.Background(), []byte("t"))
+		reply <- err
+	}()
+	// Wait for the command to be sent before closing the client
+	_, _, err := readCommand(bufio.NewReader(device))
+	assert.NoError(t, err, "We expect the command to be received")
+	assert.NoError(t, p.Close(), "We expect no error while closing the client")
+	assert.ErrorIs(t, waitForResult(t, result), pcic.ErrClientClosed)
+	assert.ErrorIs(t, waitForResult(t, reply), pcic.ErrClientClosed)
+	assert.NoError(t, p.Close(), "We expect closing twice to succeed")
+}
+
+func TestRunMalformedData(t *testing.T) {
+	p, device := newConnClient(t)
+	result := runAsync(context.Background(), p, &PCICAsyncReceiver{})
+	assert.NoError(t, writeMessage(device, "0002", "starstop"))
+	err := waitForResult(t, result)
+	assert.Error(t, err, "We expect an error on an unknown ticket")
+	assert.False(t,
+		errors.Is(err, pcic.ErrConnectionLost) || errors.Is(err, pcic.ErrClientClosed),
+		"We expect the protocol error to be returned",
+	)
+}
@@ -0,0 +1,270 @@
+package pcic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// OutputSchema describes which chunks the device sends in the asynchronous output
+	OutputSchema struct {
+		startMarker string      // The string sent before the first chunk
+		stopMarker  string      // The string sent after the last chunk
+		delimiter   string      // An optional string sent between the chunks
+		chunks      []ChunkType // The chunks in the order they are sent
+	}
+	OutputSchemaOption func(s *OutputSchema)
+)
+
+const (
+	schemaLayouter       string = "flexible"
+	schemaDataEncoding   string = "ascii"
+	schemaTypeString     string = "string"
+	schemaTypeBlob       string = "blob"
+	schemaStartElementID string = "start_string"
+	schemaStopElementID  string = "end_string"
+	schemaDelimiterID    string = "delimiter"
+)
+
+// The JSON representation of the PCIC layouter schema
+type (
+	schemaDocument struct {
+		Layouter string          `json:"layouter"`
+		Format   schemaFormat    `json:"format"`
+		Elements []schemaElement `json:"elements"`
+	}
+	schemaFormat struct {
+		DataEncoding string `json:"dataencoding"`
+	}
+	schemaElement struct {
+		Type  string `json:"type"`
+		ID    string `json:"id"`
+		Value string `json:"value,omitempty"`
+	}
+)
+
+// NewOutputSchema creates a new schema with the default start and stop markers
+func NewOutputSchema(options ...OutputSchemaOption) *OutputSchema {
+	schema := &OutputSchema{
+		startMarker: startMarker,
+		stopMarker:  endMarker,
+		chunks:      []ChunkType{},
+	}
+	// Apply options
+	for _, opt := range options {
+		opt(schema)
+	}
+	return schema
+}
+
+// WithChunks appends the given chunk types to the schema
+func WithChunks(types ...ChunkType) OutputSchemaOption {
+	return func(s *OutputSchema) {
+		s.chunks = append(s.chunks, types...)
+	}
+}
+
+// WithMarkers sets the strings sent before the first and after the last chunk
+//
+// Note: ProcessIncomming expects the default "star" and "stop" markers.
+func WithMarkers(start, stop string) OutputSchemaOption {
+	return func(s *OutputSchema) {
+		s.startMarker = start
+		s.stopMarker = stop
+	}
+}
+
+// WithDelimiter sets a string which is sent between the chunks
+//
+// Note: ProcessIncomming expects the chunks to follow each other without a delimiter.
+func WithDelimiter(delimiter string) OutputSchemaOption {
+	return func(s *OutputSchema) {
+		s.delimiter = delimiter
+	}
+}
+
+// Chunks returns the chunk types in the order they are requested
+func (s *OutputSchema) Chunks() []ChunkType {
+	return s.chunks
+}
+
+// Markers returns the start and the stop marker of the schema
+func (s *OutputSchema) Markers() (string, string) {
+	return s.startMarker, s.stopMarker
+}
+
+// Delimiter returns the string sent between the chunks
+func (s *OutputSchema) Delimiter() string {
+	return s.delimiter
+}
+
+// MarshalJSON creates the JSON layouter schema the device expects
+func (s *OutputSchema) MarshalJSON() ([]byte, error) {
+	doc := schemaDocument{
+		Layouter: schemaLayouter,
+		Format:   schemaFormat{DataEncoding: schemaDataEncoding},
+		Elements: []schemaElement{},
+	}
+	if s.startMarker != "" {
+		doc.Elements = append(doc.Elements, schemaElement{
+			Type:  schemaTypeString,
+			ID:    schemaStartElementID,
+			Value: s.startMarker,
+		})
+	}
+	for i, chunkType := range s.chunks {
+		if i > 0 && s.delimiter != "" {
+			doc.Elements = append(doc.Elements, schemaElement{
+				Type:  schemaTypeString,
+				ID:    schemaDelimiterID,
+				Value: s.delimiter,
+			})
+		}
+		doc.Elements = append(doc.Elements, schemaElement{
+			Type: schemaTypeBlob,
+			ID:   blobID(chunkType),
+		})
+	}
+	if s.stopMarker != "" {
+		doc.Elements = append(doc.Elements, schemaElement{
+			Type:  schemaTypeString,
+			ID:    schemaStopElementID,
+			Value: s.stopMarker,
+		})
+	}
+	return json.Marshal(doc)
+}
+
+// String returns the JSON representation of the schema
+func (s *OutputSchema) String() string {
+	data, err := s.MarshalJSON()
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// UnmarshalJSON parses an existing JSON layouter schema
+//
+// The string elements before the first and after the last blob are taken
+// as start and stop marker, a string element between two blobs as delimiter.
+func (s *OutputSchema) UnmarshalJSON(data []byte) error {
+	doc := schemaDocument{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Layouter != schemaLayouter {
+		return fmt.Errorf("unsupported layouter: %q expected: %q", doc.Layouter, schemaLayouter)
+	}
+	parsed := OutputSchema{chunks: []ChunkType{}}
+	// The string elements between the blobs, the first entry holds the
+	// strings before the first blob and the last entry those after the last one.
+	segments := [][]string{{}}
+	for _, element := range doc.Elements {
+		switch element.Type {
+		case schemaTypeString:
+			segments[len(segments)-1] = append(segments[len(segments)-1], element.Value)
+		case schemaTypeBlob:
+			chunkType, err := blobIDParser(element.ID)
+			if err != nil {
+				return err
+			}
+			parsed.chunks = append(parsed.chunks, chunkType)
+			segments = append(segments, []string{})
+		default:
+			return fmt.Errorf("unsupported element type: %q", element.Type)
+		}
+	}
+	if len(parsed.chunks) == 0 {
+		// Without any blob the first string is the start and the remaining ones the stop marker
+		if strs := segments[0]; len(strs) > 0 {
+			parsed.startMarker = strs[0]
+			parsed.stopMarker = strings.Join(strs[1:], "")
+		}
+		*s = parsed
+		return nil
+	}
+	parsed.startMarker = strings.Join(segments[0], "")
+	parsed.stopMarker = strings.Join(segments[len(segments)-1], "")
+	for i, segment := range segments[1 : len(segments)-1] {
+		delimiter := strings.Join(segment, "")
+		if i == 0 {
+			parsed.delimiter = delimiter
+		} else if delimiter != parsed.delimiter {
+			return fmt.Errorf("inconsistent delimiter %q expected: %q", delimiter, parsed.delimiter)
+		}
+	}
+	*s = parsed
+	return nil
+}
+
+// blobID returns the id of a blob element, the name of registered
+// chunk types as used by the device, the numeric value otherwise.
+func blobID(chunkType ChunkType) string {
+	if chunkType.Known() {
+		return chunkType.String()
+	}
+	return strconv.FormatUint(uint64(chunkType), 10)
+}
+
+// blobIDParser parses the id of a blob element, it is either
+// the numeric value or the name of the ChunkType.
+func blobIDParser(id string) (ChunkType, error) {
+	if value, err := strconv.ParseUint(id, 10, 32); err == nil {
+		return ChunkType(value), nil
+	}
+	chunkType, err := ParseChunkType(id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse the blob id: %w", err)
+	}
+	return chunkType, nil
+}
+
+// ParseOutputSchema creates an OutputSchema from its JSON representation
+func ParseOutputSchema(data []byte) (*OutputSchema, error) {
+	schema := &OutputSchema{}
+	if err := schema.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// Validate checks whether the frame contains the chunks requested by the schema
+//
+// The chunks have to be present in the same order as they are requested.
+func (s *OutputSchema) Validate(frame Frame) error {
+	if len(frame.Chunks) != len(s.chunks) {
+		return fmt.Errorf(
+			"the frame contains %d chunks but the schema requests %d",
+			len(frame.Chunks),
+			len(s.chunks),
+		)
+	}
+	for i, chunkType := range s.chunks {
+		if frame.Chunks[i].Type() != chunkType {
+			return fmt.Errorf(
+				"chunk type mismatch at position %d: %s expected: %s",
+				i,
+				frame.Chunks[i].Type(),
+				chunkType,
+			)
+		}
+	}
+	return nil
+}
+
+// SetSchema uploads the given output schema to the device
+func (p *PCICClient) SetSchema(ctx context.Context, schema *OutputSchema) error {
+	if schema == nil {
+		return errors.New("no output schema provided")
+	}
+	data, err := schema.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	return p.SetOutputSchema(ctx, string(data))
+}
@@ -0,0 +1,142 @@
+package pcic_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputSchemaJSON(t *testing.T) {
+	schema := pcic.NewOutputSchema(
+		pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE, pcic.ChunkType(300)),
+	)
+	var doc map[string]any
+	assert.NoError(t,
+		json.Unmarshal([]byte(schema.String()), &doc),
+		"We expect the schema to be valid JSON",
+	)
+	assert.Equal(t, "flexible", doc["layouter"], "A layouter mismatch occurred")
+	elements := doc["elements"].([]any)
+	assert.Equal(t, 4, len(elements), "An element count mismatch occurred")
+	assert.Equal(t,
+		map[string]any{"type": "string", "id": "start_string", "value": "star"},
+		elements[0],
+	)
+	assert.Equal(t,
+		map[string]any{"type": "blob", "id": "RADIAL_DISTANCE_NOISE"},
+		elements[1],
+	)
+	assert.Equal(t,
+		map[string]any{"type": "string", "id": "end_string", "value": "stop"},
+		elements[3],
+	)
+}
+
+func TestOutputSchemaBlobIDs(t *testing.T) {
+	schema := pcic.NewOutputSchema(
+		pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE, pcic.ChunkType(4242)),
+	)
+	assert.JSONEq(t, `{
+		"layouter": "flexible",
+		"format": {"dataencoding": "ascii"},
+		"elements": [
+			{"type": "string", "id": "start_string", "value": "star"},
+			{"type": "blob", "id": "RADIAL_DISTANCE_NOISE"},
+			{"type": "blob", "id": "4242"},
+			{"type": "string", "id": "end_string", "value": "stop"}
+		]
+	}`, schema.String(), "We expect registered chunks by name and unknown ones by number")
+}
+
+func TestOutputSchemaRoundtrip(t *testing.T) {
+	for _, schema := range []*pcic.OutputSchema{
+		pcic.NewOutputSchema(),
+		pcic.NewOutputSchema(pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE)),
+		pcic.NewOutputSchema(
+			pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE, pcic.ChunkType(300), pcic.ChunkType(4242)),
+			pcic.WithMarkers("begin", "end"),
+			pcic.WithDelimiter(";"),
+		),
+	} {
+		parsed, err := pcic.ParseOutputSchema([]byte(schema.String()))
+		assert.NoError(t, err, "We expect no error while parsing the schema")
+		assert.Equal(t, schema.Chunks(), parsed.Chunks(), "A chunk mismatch occurred")
+		assert.Equal(t, schema.Delimiter(), parsed.Delimiter(), "A delimiter mismatch occurred")
+		start, stop := schema.Markers()
+		parsedStart, parsedStop := parsed.Markers()
+		assert.Equal(t, start, parsedStart, "A start marker mismatch occurred")
+		assert.Equal(t, stop, parsedStop, "A stop marker mismatch occurred")
+	}
+}
+
+func TestParseMalformedOutputSchema(t *testing.T) {
+	for _, schema := range []string{
+		`{`,
+		`{"layouter": "fixed", "elements": []}`,
+		`{"layouter": "flexible", "elements": [{"type": "blob", "id": "abc"}]}`,
+		`{"layouter": "flexible", "elements": [{"type": "blob", "id": "-1"}]}`,
+		`{"layouter": "flexible", "elements": [{"type": "uint32", "id": "1"}]}`,
+		`{"layouter": "flexible", "elements": [
+			{"type": "blob", "id": "1"},
+			{"type": "string", "id": "delimiter", "value": ";"},
+			{"type": "blob", "id": "2"},
+			{"type": "string", "id": "delimiter", "value": ","},
+			{"type": "blob", "id": "3"}
+		]}`,
+	} {
+		_, err := pcic.ParseOutputSchema([]byte(schema))
+		assert.Error(t, err, "We expect an error while parsing: %s", schema)
+	}
+}
+
+func TestOutputSchemaValidate(t *testing.T) {
+	schema := pcic.NewOutputSchema(
+		pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE, pcic.ChunkType(300)),
+	)
+	frame := pcic.Frame{Chunks: []pcic.Chunk{
+		*pcic.NewChunk(pcic.WithChunkType(pcic.RADIAL_DISTANCE_NOISE)),
+		*pcic.NewChunk(pcic.WithChunkType(pcic.ChunkType(300))),
+	}}
+	assert.NoError(t, schema.Validate(frame), "We expect the frame to match the schema")
+
+	frame.Chunks[1] = *pcic.NewChunk(pcic.WithChunkType(pcic.ChunkType(301)))
+	assert.Error(t, schema.Validate(frame), "We expect a chunk type mismatch")
+
+	frame.Chunks = frame.Chunks[:1]
+	assert.Error(t, schema.Validate(frame), "We expect a chunk count mismatch")
+}
+
+func TestSetSchema(t *testing.T) {
+	schema := pcic.NewOutputSchema(pcic.WithChunks(pcic.RADIAL_DISTANCE_NOISE))
+	p, device := newPipeClient(t)
+	go receiveLoop(p, &PCICAsyncReceiver{})
+	received := replyOnce(device, "*")
+	assert.NoError(t,
+		p.SetSchema(context.Background(), schema),
+		"We expect no error when the device acknowledges the schema",
+	)
+	assert.Equal(t,
+		fmt.Sprintf("c%09d%s", len(schema.String()), schema.String()),
+		<-received,
+		"A command mismatch occurred",
+	)
+}
+
+func TestParseOutputSchemaWithNames(t *testing.T) {
+	schema, err := pcic.ParseOutputSchema([]byte(`{"layouter": "flexible", "elements": [
+		{"type": "string", "id": "start_string", "value": "star"},
+		{"type": "blob", "id": "TOF_INFO"},
+		{"type": "blob", "id": "260"},
+		{"type": "string", "id": "end_string", "value": "stop"}
+	]}`))
+	assert.NoError(t, err, "We expect no error while parsing the schema")
+	assert.Equal(t,
+		[]pcic.ChunkType{pcic.TOF_INFO, pcic.JPEG_IMAGE},
+		schema.Chunks(),
+		"A chunk mismatch occurred",
+	)
+}
@@ -0,0 +1,226 @@
+package pcic
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// DropPolicy defines how a Subscription behaves when its buffer is full
+	DropPolicy int
+
+	// Broker is a MessageHandler which distributes the received messages to any
+	// number of independent subscriptions.
+	//
+	// Pass the Broker to PCICClient.Run or ProcessIncomming and consume the
+	// messages through the channels of the subscriptions.
+	Broker struct {
+		mutex         sync.RWMutex
+		subscriptions map[*Subscription]struct{}
+		closed        bool
+	}
+
+	// Subscription provides the messages received by a Broker as Go channels
+	Subscription struct {
+		broker        *Broker
+		bufferSize    int
+		policy        DropPolicy
+		frames        chan Frame
+		errors        chan ErrorMessage
+		notifications chan NotificationMessage
+		mutex         sync.Mutex    // Serializes the delivery and the closing of the channels
+		closed        bool          // Set as soon as the channels are closed
+		done          chan struct{} // Closed to unblock a pending delivery
+		closeOnce     sync.Once
+		dropped       atomic.Uint64
+	}
+	SubscriptionOption func(s *Subscription)
+)
+
+// The drop policies of a Subscription
+const (
+	Block      DropPolicy = iota // Wait until the subscriber has consumed a message, this stalls all other subscribers
+	DropOldest                   // Discard the oldest buffered message to make room for the new one
+	LatestOnly                   // Only keep the latest message, the buffer size is ignored
+)
+
+const defaultBufferSize int = 1
+
+// NewBroker creates a Broker without any subscriptions
+func NewBroker() *Broker {
+	return &Broker{
+		subscriptions: make(map[*Subscription]struct{}),
+	}
+}
+
+// WithBufferSize sets the number of messages buffered per channel
+func WithBufferSize(size int) SubscriptionOption {
+	return func(s *Subscription) {
+		s.bufferSize = size
+	}
+}
+
+// WithDropPolicy sets the behavior when the buffer of the subscription is full
+func WithDropPolicy(policy DropPolicy) SubscriptionOption {
+	return func(s *Subscription) {
+		s.policy = policy
+	}
+}
+
+// Subscribe creates a new subscription, by default a single message is
+// buffered and the delivery blocks until the subscriber consumed it.
+//
+// The subscription has to be closed once it is no longer used. In case the
+// Broker is already closed the channels of the subscription are closed as well.
+func (b *Broker) Subscribe(options ...SubscriptionOption) *Subscription {
+	sub := &Subscription{
+		broker:     b,
+		bufferSize: defaultBufferSize,
+		policy:     Block,
+		done:       make(chan struct{}),
+	}
+	// Apply options
+	for _, opt := range options {
+		opt(sub)
+	}
+	if sub.policy == LatestOnly || sub.bufferSize < 1 {
+		sub.bufferSize = 1
+	}
+	sub.frames = make(chan Frame, sub.bufferSize)
+	sub.errors = make(chan ErrorMessage, sub.bufferSize)
+	sub.notifications = make(chan NotificationMessage, sub.bufferSize)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.closed {
+		sub.closeChannels()
+		return sub
+	}
+	b.subscriptions[sub] = struct{}{}
+	return sub
+}
+
+// Close closes all subscriptions, this ends the range loops over their channels
+func (b *Broker) Close() {
+	b.mutex.Lock()
+	subscriptions := b.subscriptions
+	b.subscriptions = make(map[*Subscription]struct{})
+	b.closed = true
+	b.mutex.Unlock()
+	for sub := range subscriptions {
+		sub.closeChannels()
+	}
+}
+
+// snapshot returns the current subscriptions, the lock is not held
+// during the delivery so a blocked subscriber can always be closed.
+func (b *Broker) snapshot() []*Subscription {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	subscriptions := make([]*Subscription, 0, len(b.subscriptions))
+	for sub := range b.subscriptions {
+		subscriptions = append(subscriptions, sub)
+	}
+	return subscriptions
+}
+
+// Result distributes the frame to all subscriptions
+//
+// A pooled Frame is cloned and released right away, so the subscribers
+// never have to call Frame.Release. This is intended: the subscribers consume
+// the frame concurrently and with independent lifetimes, so none of them can
+// own the pooled buffer. Use a MessageHandler directly for the zero-copy path.
+func (b *Broker) Result(frame Frame) {
+	if frame.buffer != nil {
+		pooled := frame
+		frame = pooled.Clone()
+		pooled.Release()
+	}
+	for _, sub := range b.snapshot() {
+		deliver(sub, sub.frames, frame)
+	}
+}
+
+// Error distributes the error message to all subscriptions
+func (b *Broker) Error(msg ErrorMessage) {
+	for _, sub := range b.snapshot() {
+		deliver(sub, sub.errors, msg)
+	}
+}
+
+// Notification distributes the notification message to all subscriptions
+func (b *Broker) Notification(msg NotificationMessage) {
+	for _, sub := range b.snapshot() {
+		deliver(sub, sub.notifications, msg)
+	}
+}
+
+// deliver sends the value to the channel according to the drop policy of the subscription
+func deliver[T any](sub *Subscription, ch chan T, value T) {
+	sub.mutex.Lock()
+	defer sub.mutex.Unlock()
+	if sub.closed {
+		return
+	}
+	if sub.policy == Block {
+		select {
+		case ch <- value:
+		case <-sub.done:
+		}
+		return
+	}
+	for {
+		select {
+		case ch <- value:
+			return
+		default:
+		}
+		// The buffer is full, discard the oldest message
+		select {
+		case <-ch:
+			sub.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+// Frames returns the channel the frames are delivered to
+func (s *Subscription) Frames() <-chan Frame {
+	return s.frames
+}
+
+// Errors returns the channel the error messages are delivered to
+func (s *Subscription) Errors() <-chan ErrorMessage {
+	return s.errors
+}
+
+// Notifications returns the channel the notification messages are delivered to
+func (s *Subscription) Notifications() <-chan NotificationMessage {
+	return s.notifications
+}
+
+// Dropped returns the number of messages discarded due to the drop policy
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close removes the subscription from the Broker and closes its channels
+func (s *Subscription) Close() {
+	s.closeChannels()
+	s.broker.mutex.Lock()
+	defer s.broker.mutex.Unlock()
+	delete(s.broker.subscriptions, s)
+}
+
+// closeChannels unblocks a pending delivery and closes the channels
+func (s *Subscription) closeChannels() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.closed = true
+		close(s.frames)
+		close(s.errors)
+		close(s.notifications)
+	})
+}
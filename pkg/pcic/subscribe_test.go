@@ -0,0 +1,90 @@
+package pcic_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+func frameWithCount(count uint32) pcic.Frame {
+	chunk := pcic.NewChunk(pcic.WithChunkType(pcic.RADIAL_DISTANCE_NOISE))
+	chunk.SetFrameCount(count)
+	return pcic.Frame{Chunks: []pcic.Chunk{*chunk}}
+}
+
+func TestSubscriptionMultipleSubscribers(t *testing.T) {
+	broker := pcic.NewBroker()
+	first := broker.Subscribe(pcic.WithBufferSize(3))
+	second := broker.Subscribe(pcic.WithBufferSize(3))
+	broker.Result(frameWithCount(1))
+	broker.Error(pcic.ErrorMessage{ID: 42})
+	broker.Notification(pcic.NotificationMessage{ID: 500000, Message: "{}"})
+	for _, sub := range []*pcic.Subscription{first, second} {
+		frame := <-sub.Frames()
+		assert.Equal(t, uint32(1), frame.Chunks[0].FrameCount(), "A frame mismatch occurred")
+		assert.Equal(t, 42, (<-sub.Errors()).ID, "An error message mismatch occurred")
+		assert.Equal(t, 500000, (<-sub.Notifications()).ID, "A notification mismatch occurred")
+	}
+	broker.Close()
+	_, ok := <-first.Frames()
+	assert.False(t, ok, "We expect the channel to be closed")
+	_, ok = <-broker.Subscribe().Frames()
+	assert.False(t, ok, "We expect the channel of a late subscription to be closed")
+}
+
+func TestSubscriptionDropOldest(t *testing.T) {
+	broker := pcic.NewBroker()
+	sub := broker.Subscribe(pcic.WithBufferSize(2), pcic.WithDropPolicy(pcic.DropOldest))
+	defer sub.Close()
+	for i := uint32(1); i <= 5; i++ {
+		broker.Result(frameWithCount(i))
+	}
+	assert.Equal(t, uint64(3), sub.Dropped(), "A dropped count mismatch occurred")
+	assert.Equal(t, uint32(4), (<-sub.Frames()).Chunks[0].FrameCount())
+	assert.Equal(t, uint32(5), (<-sub.Frames()).Chunks[0].FrameCount())
+}
+
+func TestSubscriptionLatestOnly(t *testing.T) {
+	broker := pcic.NewBroker()
+	sub := broker.Subscribe(pcic.WithBufferSize(10), pcic.WithDropPolicy(pcic.LatestOnly))
+	defer sub.Close()
+	for i := uint32(1); i <= 5; i++ {
+		broker.Result(frameWithCount(i))
+	}
+	assert.Equal(t, uint32(5), (<-sub.Frames()).Chunks[0].FrameCount())
+	assert.Equal(t, 0, len(sub.Frames()), "We expect only the latest frame")
+}
+
+func TestSubscriptionSlowConsumer(t *testing.T) {
+	broker := pcic.NewBroker()
+	slow := broker.Subscribe(pcic.WithDropPolicy(pcic.LatestOnly))
+	defer slow.Close()
+	fast := broker.Subscribe(pcic.WithBufferSize(10))
+	defer fast.Close()
+	for i := uint32(1); i <= 10; i++ {
+		broker.Result(frameWithCount(i))
+	}
+	assert.Equal(t, 10, len(fast.Frames()), "We expect the fast consumer to receive all frames")
+	assert.Equal(t, uint64(9), slow.Dropped(), "We expect the slow consumer to drop frames")
+}
+
+func TestSubscriptionCloseUnblocks(t *testing.T) {
+	broker := pcic.NewBroker()
+	sub := broker.Subscribe()
+	broker.Result(frameWithCount(1))
+	delivered := make(chan struct{})
+	go func() {
+		// The buffer is full, so this blocks until the subscription is closed
+		broker.Result(frameWithCount(2))
+		close(delivered)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sub.Close()
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("The delivery was not unblocked by closing the subscription")
+	}
+}
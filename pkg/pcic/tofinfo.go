@@ -0,0 +1,136 @@
+package pcic
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// The supported versions of the TOF_INFO chunk
+const (
+	minTOFInfoVersion = 3
+	tofInfoVersion4   = 4
+)
+
+// TOFInfo is the calibration and acquisition state of a 3D frame
+type TOFInfo struct {
+	Version                       uint32     // The version of the TOF_INFO layout
+	DistanceResolution            float32    // The resolution of the distance image in meters per digit
+	AmplitudeResolution           float32    // The resolution of the amplitude image
+	AmplitudeNormalizationFactors [3]float32 // The amplitude normalization factors of the exposures
+	ExtrinsicOpticToUser          Extrinsic  // The transformation from the optical to the user coordinate system
+	IntrinsicCalibration          Intrinsic  // The intrinsic camera model
+	InverseIntrinsicCalibration   Intrinsic  // The inverse intrinsic camera model
+	ExposureTimestampsNsec        [3]uint64  // The timestamps of the exposures in nano seconds
+	ExposureTimes                 [3]float32 // The exposure times in seconds
+	IlluTemperature               float32    // The illumination temperature in degree Celsius
+	Mode                          string     // The name of the acquisition mode
+	Imager                        string     // The name of the imager
+	MeasurementBlockIndex         uint32     // The index of the measurement block, available since version 4
+	MeasurementRangeMin           float32    // The minimum measurement range in meters, available since version 4
+	MeasurementRangeMax           float32    // The maximum measurement range in meters, available since version 4
+}
+
+// tofInfoLayoutV3 is the binary layout of the version 3 TOF_INFO chunk
+type tofInfoLayoutV3 struct {
+	Version                       uint32
+	DistanceResolution            float32
+	AmplitudeResolution           float32
+	AmplitudeNormalizationFactors [3]float32
+	ExtrinsicOpticToUser          Extrinsic
+	IntrinsicCalibration          Intrinsic
+	InverseIntrinsicCalibration   Intrinsic
+	ExposureTimestampsNsec        [3]uint64
+	ExposureTimes                 [3]float32
+	IlluTemperature               float32
+	Mode                          [32]byte
+	Imager                        [32]byte
+}
+
+// tofInfoLayoutV4 is the extension of the version 4 TOF_INFO chunk
+type tofInfoLayoutV4 struct {
+	MeasurementBlockIndex uint32
+	MeasurementRangeMin   float32
+	MeasurementRangeMax   float32
+}
+
+// TOFInfoFromChunk decodes the TOF_INFO chunk
+func TOFInfoFromChunk(c *Chunk) (*TOFInfo, error) {
+	if c.chunkType != TOF_INFO {
+		return nil, fmt.Errorf("the chunk %s is not a %s chunk", c.chunkType, TOF_INFO)
+	}
+	info := &TOFInfo{}
+	if err := info.UnmarshalBinary(c.data); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// UnmarshalBinary decodes the data section of a TOF_INFO chunk
+//
+// Newer versions only append fields, so the known fields of a version
+// beyond the latest supported one are decoded as well.
+func (info *TOFInfo) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("the %s data is too short: %d", TOF_INFO, len(data))
+	}
+	version := binary.LittleEndian.Uint32(data)
+	if version < minTOFInfoVersion {
+		return fmt.Errorf(
+			"unsupported %s version: %d minimum supported version: %d",
+			TOF_INFO,
+			version,
+			minTOFInfoVersion,
+		)
+	}
+	v3 := tofInfoLayoutV3{}
+	if err := decodeVersioned(data, &v3, version); err != nil {
+		return err
+	}
+	v4 := tofInfoLayoutV4{}
+	if version >= tofInfoVersion4 {
+		if err := decodeVersioned(data[binary.Size(v3):], &v4, version); err != nil {
+			return err
+		}
+	}
+	*info = TOFInfo{
+		Version:                       v3.Version,
+		DistanceResolution:            v3.DistanceResolution,
+		AmplitudeResolution:           v3.AmplitudeResolution,
+		AmplitudeNormalizationFactors: v3.AmplitudeNormalizationFactors,
+		ExtrinsicOpticToUser:          v3.ExtrinsicOpticToUser,
+		IntrinsicCalibration:          v3.IntrinsicCalibration,
+		InverseIntrinsicCalibration:   v3.InverseIntrinsicCalibration,
+		ExposureTimestampsNsec:        v3.ExposureTimestampsNsec,
+		ExposureTimes:                 v3.ExposureTimes,
+		IlluTemperature:               v3.IlluTemperature,
+		Mode:                          cString(v3.Mode[:]),
+		Imager:                        cString(v3.Imager[:]),
+		MeasurementBlockIndex:         v4.MeasurementBlockIndex,
+		MeasurementRangeMin:           v4.MeasurementRangeMin,
+		MeasurementRangeMax:           v4.MeasurementRangeMax,
+	}
+	return nil
+}
+
+// decodeVersioned decodes the little endian fixed size layout v from the beginning of data
+func decodeVersioned(data []byte, v any, version uint32) error {
+	if size := binary.Size(v); len(data) < size {
+		return fmt.Errorf(
+			"the data of version %d is too short: %d expected at minimum: %d",
+			version,
+			len(data),
+			size,
+		)
+	}
+	return binary.Read(bytes.NewReader(data), binary.LittleEndian, v)
+}
+
+// cString converts a zero terminated, fixed size string field
+func cString(field []byte) string {
+	if i := bytes.IndexByte(field, 0); i >= 0 {
+		field = field[:i]
+	}
+	return strings.TrimSpace(string(field))
+}
@@ -0,0 +1,164 @@
+package pcic_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/graugans/go-ovp8xx/pkg/pcic"
+	"github.com/stretchr/testify/assert"
+)
+
+// tofInfoV4 mirrors the binary layout of the version 4 TOF_INFO chunk
+type tofInfoV4 struct {
+	Version                       uint32
+	DistanceResolution            float32
+	AmplitudeResolution           float32
+	AmplitudeNormalizationFactors [3]float32
+	Extrinsic                     [6]float32
+	IntrinsicModelID              uint32
+	IntrinsicParameters           [32]float32
+	InverseIntrinsicModelID       uint32
+	InverseIntrinsicParameters    [32]float32
+	ExposureTimestampsNsec        [3]uint64
+	ExposureTimes                 [3]float32
+	IlluTemperature               float32
+	Mode                          [32]byte
+	Imager                        [32]byte
+	MeasurementBlockIndex         uint32
+	MeasurementRangeMin           float32
+	MeasurementRangeMax           float32
+}
+
+func tofInfoChunk(t *testing.T, info tofInfoV4, size int) *pcic.Chunk {
+	buffer := bytes.Buffer{}
+	assert.NoError(t, binary.Write(&buffer, binary.LittleEndian, info), "We expect no error while encoding")
+	chunk := pcic.NewChunk(
+		pcic.WithChunkType(pcic.TOF_INFO),
+		pcic.WithDimension(size, 1, pcic.FORMAT_8U),
+	)
+	copy(chunk.Bytes(), buffer.Bytes())
+	return chunk
+}
+
+func testTOFInfo() tofInfoV4 {
+	info := tofInfoV4{
+		Version:                       4,
+		DistanceResolution:            0.0002,
+		AmplitudeResolution:           0.5,
+		AmplitudeNormalizationFactors: [3]float32{1, 2, 3},
+		Extrinsic:                     [6]float32{0.1, 0.2, 0.3, 0, 1.57, 3.14},
+		IntrinsicModelID:              2,
+		InverseIntrinsicModelID:       3,
+		ExposureTimestampsNsec:        [3]uint64{1000, 2000, 3000},
+		ExposureTimes:                 [3]float32{0.00003, 0.0004, 0.001},
+		IlluTemperature:               42.5,
+		MeasurementBlockIndex:         1,
+		MeasurementRangeMin:           0.1,
+		MeasurementRangeMax:           4,
+	}
+	info.IntrinsicParameters[0] = 128.5
+	info.InverseIntrinsicParameters[31] = -1
+	copy(info.Mode[:], "standard_range4m")
+	copy(info.Imager[:], "IRS2877C")
+	return info
+}
+
+func TestTOFInfoFromChunk(t *testing.T) {
+	info, err := pcic.TOFInfoFromChunk(tofInfoChunk(t, testTOFInfo(), 428))
+	assert.NoError(t, err, "We expect no error while decoding the TOF_INFO chunk")
+	assert.Equal(t, uint32(4), info.Version, "A version mismatch occurred")
+	assert.Equal(t, float32(0.0002), info.DistanceResolution, "A resolution mismatch occurred")
+	assert.Equal(t, [3]float32{1, 2, 3}, info.AmplitudeNormalizationFactors)
+	assert.Equal(t,
+		pcic.Extrinsic{TransX: 0.1, TransY: 0.2, TransZ: 0.3, RotX: 0, RotY: 1.57, RotZ: 3.14},
+		info.ExtrinsicOpticToUser,
+		"An extrinsic mismatch occurred",
+	)
+	assert.Equal(t, uint32(2), info.IntrinsicCalibration.ModelID, "A model ID mismatch occurred")
+	assert.Equal(t, float32(128.5), info.IntrinsicCalibration.Parameters[0])
+	assert.Equal(t, uint32(3), info.InverseIntrinsicCalibration.ModelID, "A model ID mismatch occurred")
+	assert.Equal(t, float32(-1), info.InverseIntrinsicCalibration.Parameters[31])
+	assert.Equal(t, [3]uint64{1000, 2000, 3000}, info.ExposureTimestampsNsec)
+	assert.Equal(t, [3]float32{0.00003, 0.0004, 0.001}, info.ExposureTimes)
+	assert.Equal(t, float32(42.5), info.IlluTemperature, "A temperature mismatch occurred")
+	assert.Equal(t, "standard_range4m", info.Mode, "A mode mismatch occurred")
+	assert.Equal(t, "IRS2877C", info.Imager, "An imager mismatch occurred")
+	assert.Equal(t, uint32(1), info.MeasurementBlockIndex, "A block index mismatch occurred")
+	assert.Equal(t, float32(4), info.MeasurementRangeMax, "A range mismatch occurred")
+}
+
+func TestTOFInfoVersions(t *testing.T) {
+	v3 := testTOFInfo()
+	v3.Version = 3
+	info, err := pcic.TOFInfoFromChunk(tofInfoChunk(t, v3, 416))
+	assert.NoError(t, err, "We expect no error while decoding a version 3 chunk")
+	assert.Equal(t, "standard_range4m", info.Mode, "A mode mismatch occurred")
+	assert.Equal(t, uint32(0), info.MeasurementBlockIndex, "We expect no version 4 fields")
+
+	v5 := testTOFInfo()
+	v5.Version = 5
+	info, err = pcic.TOFInfoFromChunk(tofInfoChunk(t, v5, 500))
+	assert.NoError(t, err, "We expect the known fields of a newer version to be decoded")
+	assert.Equal(t, float32(4), info.MeasurementRangeMax, "A range mismatch occurred")
+
+	v2 := testTOFInfo()
+	v2.Version = 2
+	_, err = pcic.TOFInfoFromChunk(tofInfoChunk(t, v2, 428))
+	assert.Error(t, err, "We expect an error due to an unsupported version")
+
+	_, err = pcic.TOFInfoFromChunk(tofInfoChunk(t, testTOFInfo(), 416))
+	assert.Error(t, err, "We expect an error due to truncated version 4 data")
+
+	_, err = pcic.TOFInfoFromChunk(pcic.NewChunk(pcic.WithChunkType(pcic.RGB_INFO)))
+	assert.Error(t, err, "We expect an error due to a chunk type mismatch")
+}
+
+func TestTOFInfoWithFixture(t *testing.T) {
+	for _, frame := range fixtureFrames(t) {
+		chunk, ok := frame.ChunkByType(pcic.TOF_INFO)
+		assert.True(t, ok, "We expect the fixture to contain the TOF_INFO chunk")
+		info, err := pcic.TOFInfoFromChunk(chunk)
+		assert.NoError(t, err, "We expect no error while decoding the TOF_INFO chunk")
+		assert.Equal(t, uint32(4), info.Version, "A version mismatch occurred")
+		assert.Equal(t, float32(0.001), info.DistanceResolution, "A resolution mismatch occurred")
+		assert.Equal(t, "standard_range4m", info.Mode, "A mode mismatch occurred")
+		assert.Equal(t, "IRS2381C", info.Imager, "An imager mismatch occurred")
+		assert.Equal(t, [3]uint64{1, 2, 3}, info.ExposureTimestampsNsec, "A timestamp mismatch occurred")
+		assert.Equal(t, float32(42.5), info.IlluTemperature, "A temperature mismatch occurred")
+		assert.Equal(t, float32(4), info.MeasurementRangeMax, "A range mismatch occurred")
+		assert.Equal(t, uint32(1), info.InverseIntrinsicCalibration.ModelID, "A model mismatch occurred")
+	}
+}
+
+func TestTOFInfoWithRecordedData(t *testing.T) {
+	data := recordedMessages(t, "testdata/pcic-test-data.blob.bz2")
+	p, err := pcic.NewPCICClient(
+		pcic.WithBufioReaderWriter(bufio.NewReadWriter(bufio.NewReader(bytes.NewReader(data)), nil)),
+	)
+	assert.NoError(t, err, "We expect no error while creating the PCICClient")
+	handler := &PCICAsyncReceiver{}
+	decoded := 0
+	for {
+		err := p.ProcessIncomming(handler)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		assert.NoError(t, err, "No error expected while reading the recorded data")
+		chunk, ok := handler.frame.ChunkByType(pcic.TOF_INFO)
+		if !ok {
+			continue
+		}
+		info, err := pcic.TOFInfoFromChunk(chunk)
+		assert.NoError(t, err, "We expect no error while decoding the TOF_INFO chunk")
+		assert.GreaterOrEqual(t, info.Version, uint32(3), "A version mismatch occurred")
+		assert.NotEmpty(t, info.Mode, "We expect an acquisition mode")
+		decoded++
+	}
+	if decoded == 0 {
+		t.Skip("The recorded data contains no TOF_INFO chunk")
+	}
+}
@@ -0,0 +1,123 @@
+#!/usr/bin/env python3
+"""Generate pkg/pcic/testdata/pcic-pointcloud.blob
+
+The fixture is a small synthetic PCIC stream of two 3D result frames. Each
+frame holds the TOF_INFO (version 4), RADIAL_DISTANCE_IMAGE (16U),
+UNIT_VECTOR_ALL, CONFIDENCE_IMAGE and the CARTESIAN_X/Y/Z_COMPONENT chunks.
+The data is not captured from a device. The Cartesian chunks follow the same
+convention as the pcic package: the unit vector scaled by the radial distance,
+rotated by R = Rx * Ry * Rz and translated by the extrinsic calibration. The
+fixture is a unit test of the decoding and the handling of invalid pixels, the
+comparison with the output of a device uses the recorded test data.
+
+Usage: python3 scripts/pointcloud-fixture.py > pkg/pcic/testdata/pcic-pointcloud.blob
+"""
+
+import math
+import struct
+import sys
+
+WIDTH, HEIGHT = 8, 6
+DISTANCE_RESOLUTION = 0.001
+EXTRINSIC = (0.1, -0.05, 0.3, 0.05, -0.1, 0.2)
+FX, FY, MX, MY = 6.0, 6.0, 3.5, 2.5
+
+FORMAT_8U, FORMAT_16U, FORMAT_32F = 0, 2, 6
+RADIAL_DISTANCE_IMAGE, CARTESIAN_X, CARTESIAN_Y, CARTESIAN_Z = 100, 200, 201, 202
+UNIT_VECTOR_ALL, CONFIDENCE_IMAGE, TOF_INFO = 223, 300, 420
+
+
+def chunk(chunk_type, width, height, data_format, data, frame_count):
+    header = struct.pack(
+        "<12I",
+        chunk_type,
+        48 + len(data),
+        48,
+        2,
+        width,
+        height,
+        data_format,
+        0,
+        frame_count,
+        0,
+        1700000000 + frame_count,
+        0,
+    )
+    return header + data
+
+
+def intrinsic(model_id, parameters):
+    return struct.pack("<I32f", model_id, *(parameters + [0.0] * (32 - len(parameters))))
+
+
+def tof_info():
+    return (
+        struct.pack("<I2f3f6f", 4, DISTANCE_RESOLUTION, 0.1, 1.0, 1.0, 1.0, *EXTRINSIC)
+        + intrinsic(0, [FX, FY, MX, MY, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0])
+        + intrinsic(1, [FX, FY, MX, MY, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0])
+        + struct.pack("<3Q3f f", 1, 2, 3, 0.001, 0.0002, 0.00004, 42.5)
+        + b"standard_range4m".ljust(32, b"\0")
+        + b"IRS2381C".ljust(32, b"\0")
+        + struct.pack("<I2f", 0, 0.1, 4.0)
+    )
+
+
+def rotation(rx, ry, rz):
+    cx, sx = math.cos(rx), math.sin(rx)
+    cy, sy = math.cos(ry), math.sin(ry)
+    cz, sz = math.cos(rz), math.sin(rz)
+    mx = [[1, 0, 0], [0, cx, -sx], [0, sx, cx]]
+    my = [[cy, 0, sy], [0, 1, 0], [-sy, 0, cy]]
+    mz = [[cz, -sz, 0], [sz, cz, 0], [0, 0, 1]]
+
+    def mul(a, b):
+        return [[sum(a[i][k] * b[k][j] for k in range(3)) for j in range(3)] for i in range(3)]
+
+    return mul(mul(mx, my), mz)
+
+
+def unit_vectors():
+    vectors = []
+    for y in range(HEIGHT):
+        for x in range(WIDTH):
+            v = ((x - MX) / FX, (y - MY) / FY, 1.0)
+            n = math.sqrt(sum(c * c for c in v))
+            vectors.append(tuple(c / n for c in v))
+    return vectors
+
+
+def frame(frame_count):
+    vectors = unit_vectors()
+    r = rotation(*EXTRINSIC[3:])
+    t = EXTRINSIC[:3]
+    digits, confidence, cartesian = [], [], ([], [], [])
+    for i, u in enumerate(vectors):
+        d = 1000 + 37 * i + 250 * frame_count
+        flags = 0
+        if i == 5:
+            d = 0
+        if i == 17:
+            flags = 1  # CONFIDENCE_INVALID
+        digits.append(d)
+        confidence.append(flags)
+        # The device computes in single precision
+        meters = struct.unpack("<f", struct.pack("<f", d * struct.unpack("<f", struct.pack("<f", DISTANCE_RESOLUTION))[0]))[0]
+        p = [c * meters for c in u]
+        valid = d > 0 and flags & 1 == 0
+        for axis in range(3):
+            value = sum(r[axis][k] * p[k] for k in range(3)) + t[axis] if valid else 0.0
+            cartesian[axis].append(value)
+    chunks = [
+        chunk(TOF_INFO, len(tof_info()), 1, FORMAT_8U, tof_info(), frame_count),
+        chunk(RADIAL_DISTANCE_IMAGE, WIDTH, HEIGHT, FORMAT_16U, struct.pack(f"<{len(digits)}H", *digits), frame_count),
+        chunk(UNIT_VECTOR_ALL, 3 * WIDTH, HEIGHT, FORMAT_32F, struct.pack(f"<{3 * len(vectors)}f", *[c for v in vectors for c in v]), frame_count),
+        chunk(CONFIDENCE_IMAGE, WIDTH, HEIGHT, FORMAT_16U, struct.pack(f"<{len(confidence)}H", *confidence), frame_count),
+    ]
+    for chunk_type, values in zip((CARTESIAN_X, CARTESIAN_Y, CARTESIAN_Z), cartesian):
+        chunks.append(chunk(chunk_type, WIDTH, HEIGHT, FORMAT_32F, struct.pack(f"<{len(values)}f", *values), frame_count))
+    content = b"0000star" + b"".join(chunks) + b"stop\r\n"
+    return b"0000L%09d\r\n" % len(content) + content
+
+
+if __name__ == "__main__":
+    sys.stdout.buffer.write(frame(1) + frame(2))